@@ -0,0 +1,162 @@
+package goquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node parsed condition, one of And, Or, Not or Compare
+type Node interface {
+	node()
+	String() string
+}
+
+// And all children must match, an empty And always matches
+type And []Node
+
+// Or any child must match, an empty Or never matches
+type Or []Node
+
+// Not negate a condition
+type Not struct {
+	Node Node
+}
+
+// Compare compare a column with a value
+type Compare struct {
+	// Table table alias the field belongs to
+	Table string
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+func (And) node()     {}
+func (Or) node()      {}
+func (Not) node()     {}
+func (Compare) node() {}
+
+func (n And) String() string {
+	return joinNodes(n, " AND ", "TRUE")
+}
+
+func (n Or) String() string {
+	return joinNodes(n, " OR ", "FALSE")
+}
+
+func (n Not) String() string {
+	return fmt.Sprintf("NOT %s", n.Node)
+}
+
+func (n Compare) String() string {
+	return fmt.Sprintf("%s.%s %s %#v", n.Table, n.Field, n.Op, n.Value)
+}
+
+func joinNodes(nodes []Node, sep string, empty string) string {
+	if len(nodes) == 0 {
+		return empty
+	}
+	parts := []string{}
+	for _, n := range nodes {
+		parts = append(parts, n.String())
+	}
+	return fmt.Sprintf("(%s)", strings.Join(parts, sep))
+}
+
+// Visitor visit nodes in Walk. If Visit returns a non-nil visitor w, the
+// children of node are walked with w, followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverse a condition tree in depth-first order
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case And:
+		{
+			for _, c := range n {
+				Walk(v, c)
+			}
+		}
+	case Or:
+		{
+			for _, c := range n {
+				Walk(v, c)
+			}
+		}
+	case Not:
+		{
+			Walk(v, n.Node)
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverse a condition tree in depth-first order, children of node
+// are skipped when f returns false. Like Walk, f(nil) is called after the
+// children of a node
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite rebuild a condition tree bottom-up, replacing each node with the
+// result of f. Children are rewritten before their parent, a child rewritten
+// to nil is dropped
+func Rewrite(node Node, f func(Node) (Node, error)) (Node, error) {
+	switch n := node.(type) {
+	case And:
+		{
+			children, err := rewriteAll(n, f)
+			if err != nil {
+				return nil, err
+			}
+			return f(And(children))
+		}
+	case Or:
+		{
+			children, err := rewriteAll(n, f)
+			if err != nil {
+				return nil, err
+			}
+			return f(Or(children))
+		}
+	case Not:
+		{
+			child, err := Rewrite(n.Node, f)
+			if err != nil || child == nil {
+				return nil, err
+			}
+			return f(Not{child})
+		}
+	default:
+		{
+			return f(node)
+		}
+	}
+}
+
+func rewriteAll(nodes []Node, f func(Node) (Node, error)) ([]Node, error) {
+	children := []Node{}
+	for _, c := range nodes {
+		child, err := Rewrite(c, f)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
@@ -0,0 +1,146 @@
+package goquery
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_Parse(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	tests := []struct {
+		name    string
+		filter  Filter
+		want    Node
+		wantErr bool
+	}{
+		{
+			name: "tree",
+			filter: Filter{
+				From: "table1",
+				Where: map[string]interface{}{
+					"a": 1,
+					"$not": map[string]interface{}{
+						"b": map[string]interface{}{"$in": []int{1, 2}},
+					},
+				},
+			},
+			want: And{
+				And{Not{And{And{Compare{Table: "table1", Field: "b", Op: OpIn, Value: []int{1, 2}}}}}},
+				And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}},
+			},
+		},
+		{
+			name: "$or",
+			filter: Filter{
+				From: "table1",
+				Where: map[string]interface{}{
+					"$or": []interface{}{
+						map[string]interface{}{"a": 1},
+						map[string]interface{}{"b": 2},
+					},
+				},
+			},
+			want: And{And{Or{
+				And{And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}}},
+				And{And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}}},
+			}}},
+		},
+		{
+			name: "invalid operand",
+			filter: Filter{
+				From: "table1",
+				Where: map[string]interface{}{
+					"a": map[string]interface{}{"$gt": nil},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := builder.Parse(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Where, tt.want) {
+				t.Errorf("Builder.Parse() = %v, want %v", got.Where, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	node := And{
+		Or{
+			Compare{Table: "t", Field: "a", Op: OpEq, Value: 1},
+			Not{Compare{Table: "t", Field: "b", Op: OpEq, Value: 2}},
+		},
+		Compare{Table: "t", Field: "c", Op: OpEq, Value: 3},
+	}
+	fields := []string{}
+	Inspect(node, func(n Node) bool {
+		if c, ok := n.(Compare); ok {
+			fields = append(fields, c.Field)
+		}
+		_, isNot := n.(Not)
+		return !isNot
+	})
+	want := []string{"a", "c"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Inspect() visited %v, want %v", fields, want)
+	}
+}
+
+func TestQuery_Rewrite(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	query, err := builder.Parse(Filter{
+		From: "users",
+		Where: map[string]interface{}{
+			"name":   "x",
+			"secret": 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Builder.Parse() error = %v", err)
+	}
+	// rename `name` and drop conditions on `secret`
+	err = query.Rewrite(func(n Node) (Node, error) {
+		c, ok := n.(Compare)
+		if !ok {
+			return n, nil
+		}
+		switch c.Field {
+		case "name":
+			c.Field = "display_name"
+			return c, nil
+		case "secret":
+			return nil, nil
+		}
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("Query.Rewrite() error = %v", err)
+	}
+	im, err := builder.BuildQuery(query)
+	if err != nil {
+		t.Fatalf("Builder.BuildQuery() error = %v", err)
+	}
+	got, _, _ := im.ToSql()
+	want := "SELECT * FROM users WHERE ((users.display_name = ?) AND (1=1))"
+	if got != want {
+		t.Errorf("Builder.BuildQuery() = %v, want %v", got, want)
+	}
+
+	wantErr := errors.New("forbidden")
+	err = query.Rewrite(func(n Node) (Node, error) {
+		return nil, wantErr
+	})
+	if err != wantErr {
+		t.Errorf("Query.Rewrite() error = %v, want %v", err, wantErr)
+	}
+}
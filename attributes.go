@@ -4,9 +4,29 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
-func (b *builderContext) attrBuild(attributes []interface{}, tableName string) ([]string, error) {
+// attrBuild qualified attributes of the main table, all of its columns
+// without attributes. With joined tables the columns are qualified, a bare
+// `*` would also select the columns of the joined tables
+func (b *builderContext) attrBuild(attributes []interface{}, tableName string, joined bool) ([]string, error) {
+	attrs, err := b.attrList(attributes, tableName, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs) == 0 {
+		attrs = []string{"*"}
+		if joined {
+			attrs = []string{b.quote(tableName) + ".*"}
+		}
+	}
+	return attrs, nil
+}
+
+// attrList qualified attributes of a table, result columns are prefixed
+// with path
+func (b *builderContext) attrList(attributes []interface{}, tableName string, path string) ([]string, error) {
 	attrs := []string{}
 	for _, v := range attributes {
 		rv := reflect.ValueOf(v)
@@ -16,6 +36,9 @@ func (b *builderContext) attrBuild(attributes []interface{}, tableName string) (
 				str := rv.String()
 				colName := b.toFullName(tableName, str)
 				aliased := fmt.Sprintf("%s AS %s", colName, b.quote(str))
+				if path != "" {
+					aliased = fmt.Sprintf("%s AS %s", colName, b.quoteAlias(path+"."+str))
+				}
 				attrs = append(attrs, aliased)
 				break
 			}
@@ -25,8 +48,50 @@ func (b *builderContext) attrBuild(attributes []interface{}, tableName string) (
 			}
 		}
 	}
-	if len(attrs) == 0 {
-		attrs = []string{"*"}
+	return attrs, nil
+}
+
+// includeAttrs selected columns of the joined includes, all selectable
+// columns of the schema when an include has no attributes. An include
+// without columns to select is an error, its rows could not be told apart
+// from unmatched ones. Result columns are named by include path relative
+// to base
+func (b *builderContext) includeAttrs(includes []*IncludeQuery, base string) ([]string, error) {
+	attrs := []string{}
+	for _, iq := range joinedIncludes(includes) {
+		list := iq.Include.Attributes
+		if len(list) == 0 && b.builder.config.Schema != nil {
+			for _, column := range b.builder.config.Schema[iq.Include.Table].columns() {
+				list = append(list, column)
+			}
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("include %s: no attributes to select", iq.Path)
+		}
+		cols, err := b.attrList(list, iq.Alias, strings.TrimPrefix(iq.Path, base))
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, cols...)
 	}
 	return attrs, nil
 }
+
+// quoteAlias quote a result column alias, which may contain dots, with
+// double quotes when the dialect does not quote
+func (b *builderContext) quoteAlias(name string) string {
+	dialect := b.builder.dialect()
+	if dialect.QuoteOpen == "" {
+		return (&Dialect{QuoteOpen: `"`, QuoteClose: `"`}).QuoteIdent(name)
+	}
+	return dialect.QuoteIdent(name)
+}
+
+func containsAttr(attributes []interface{}, name string) bool {
+	for _, v := range attributes {
+		if attr, ok := v.(string); ok && attr == name {
+			return true
+		}
+	}
+	return false
+}
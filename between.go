@@ -0,0 +1,117 @@
+package goquery
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// betweenExpr a range condition on a single column
+type betweenExpr struct {
+	column string
+	from   interface{}
+	to     interface{}
+	not    bool
+	// halfOpen render `[from, to)` instead of `BETWEEN`
+	halfOpen bool
+}
+
+func (e betweenExpr) ToSql() (string, []interface{}, error) {
+	args := []interface{}{e.from, e.to}
+	if e.halfOpen {
+		return fmt.Sprintf("(%s >= ? AND %s < ?)", e.column, e.column), args, nil
+	}
+	op := "BETWEEN"
+	if e.not {
+		op = "NOT BETWEEN"
+	}
+	return fmt.Sprintf("%s %s ? AND ?", e.column, op), args, nil
+}
+
+// rangeConds build one betweenExpr per column of m, using tmpl for the flags
+func rangeConds(m []entry, tmpl betweenExpr) ([]sq.Sqlizer, error) {
+	conds := []sq.Sqlizer{}
+	for _, e := range m {
+		k, v := e.key, e.value
+		from, to, err := rangeBounds(v)
+		if err != nil {
+			return nil, err
+		}
+		cond := tmpl
+		cond.column = k
+		cond.from = from
+		cond.to = to
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// rangeBounds split a two element array operand into its bounds, both bounds
+// must be non-null numbers, strings or times of the same kind
+func rangeBounds(v interface{}) (interface{}, interface{}, error) {
+	if !isList(v) {
+		return nil, nil, errors.New("invalid operand, range operators expect array or slice")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Len() != 2 {
+		return nil, nil, fmt.Errorf("invalid operand, range operators expect 2 elements, got %d", rv.Len())
+	}
+	from := rv.Index(0).Interface()
+	to := rv.Index(1).Interface()
+	fromClass, err := boundClass(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	toClass, err := boundClass(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fromClass != toClass {
+		return nil, nil, fmt.Errorf("invalid operand, range bounds are %s and %s", fromClass, toClass)
+	}
+	return from, to, nil
+}
+
+// boundClass classify a range bound as "number", "string" or "time"
+func boundClass(v interface{}) (string, error) {
+	if isNull(v) {
+		return "", errors.New("invalid operand, range bounds cannot be null")
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		v = value
+	}
+	if _, ok := v.(time.Time); ok {
+		return "time", nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		{
+			return "number", nil
+		}
+	case reflect.String:
+		{
+			return "string", nil
+		}
+	default:
+		{
+			if _, ok := rv.Interface().(time.Time); ok {
+				return "time", nil
+			}
+			return "", fmt.Errorf("invalid operand, unsupported range bound %T", v)
+		}
+	}
+}
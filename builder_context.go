@@ -4,8 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-
-	sq "github.com/Masterminds/squirrel"
 )
 
 type builderContext struct {
@@ -14,48 +12,40 @@ type builderContext struct {
 	tableName string
 }
 
-func (b *builderContext) parseWhere(where interface{}) (sq.Sqlizer, error) {
-	rv := reflect.ValueOf(where)
-	switch rv.Kind() {
-	case reflect.Map:
+func (b *builderContext) parseWhere(where interface{}) (Node, error) {
+	entries, ok, err := mapEntries(where)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid syntax")
+	}
+	conds := []Node{}
+	for _, e := range entries {
+		cond, err := b.parseWhereEntry(e.key, e.value)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	switch b.rel {
+	case OpAnd:
 		{
-			conds := []sq.Sqlizer{}
-			iter := rv.MapRange()
-			for iter.Next() {
-				key := iter.Key()
-				if key.Kind() != reflect.String {
-					return nil, errors.New("invalid syntax, key must be string")
-				}
-				cond, err := b.parseWhereEntry(key.String(), iter.Value().Interface())
-				if err != nil {
-					return nil, err
-				}
-				conds = append(conds, cond)
-			}
-			switch b.rel {
-			case OpAnd:
-				{
-					return sq.And(conds), nil
-				}
-			case OpOr:
-				{
-					return sq.Or(conds), nil
-				}
-			default:
-				{
-					return nil, errors.New("invalid syntax, expect relation op")
-				}
-			}
+			return And(conds), nil
+		}
+	case OpOr:
+		{
+			return Or(conds), nil
 		}
 	default:
 		{
-			return nil, errors.New("invalid syntax")
+			return nil, errors.New("invalid syntax, expect relation op")
 		}
 	}
 }
 
-func (b *builderContext) parseWhereEntry(key string, value interface{}) (sq.Sqlizer, error) {
-	var cond []sq.Sqlizer
+func (b *builderContext) parseWhereEntry(key string, value interface{}) (Node, error) {
+	var cond []Node
 	if op, err := b.toOperator(key); err == nil {
 		cond, err = b.parseOp(op, value)
 		if err != nil {
@@ -70,10 +60,10 @@ func (b *builderContext) parseWhereEntry(key string, value interface{}) (sq.Sqli
 	// if len(cond) == 1 {
 	// 	return cond[0], nil
 	// }
-	return sq.And(cond), nil
+	return And(cond), nil
 }
 
-func (b *builderContext) parseOp(op Op, operand interface{}) ([]sq.Sqlizer, error) {
+func (b *builderContext) parseOp(op Op, operand interface{}) ([]Node, error) {
 	switch op {
 	case OpAnd:
 		{
@@ -81,7 +71,7 @@ func (b *builderContext) parseOp(op Op, operand interface{}) ([]sq.Sqlizer, erro
 			if err != nil {
 				return nil, err
 			}
-			return sq.And(conds), nil
+			return []Node{And(conds)}, nil
 		}
 	case OpOr:
 		{
@@ -89,12 +79,15 @@ func (b *builderContext) parseOp(op Op, operand interface{}) ([]sq.Sqlizer, erro
 			if err != nil {
 				return nil, err
 			}
-			return sq.Or(conds), nil
+			return []Node{Or(conds)}, nil
 		}
 	case OpNot:
 		{
-			return nil, errors.New("unimplementd")
-
+			cond, err := b.parseNot(operand)
+			if err != nil {
+				return nil, err
+			}
+			return []Node{cond}, nil
 		}
 	default:
 		{
@@ -102,18 +95,47 @@ func (b *builderContext) parseOp(op Op, operand interface{}) ([]sq.Sqlizer, erro
 			if err != nil {
 				return nil, err
 			}
-			return sq.And(conds), nil
+			return And(conds), nil
+		}
+	}
+}
+
+// parseNot negate a condition map (`$not: {...}`) or an array of condition
+// maps (`$not: [{...}, {...}]`), which are and-ed before negation
+func (b *builderContext) parseNot(operand interface{}) (Node, error) {
+	if _, ok, _ := mapEntries(operand); ok {
+		nb := b.inherit()
+		nb.rel = OpAnd
+		cond, err := nb.parseWhere(operand)
+		if err != nil {
+			return nil, err
+		}
+		return Not{cond}, nil
+	}
+	rv := reflect.ValueOf(operand)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+		{
+			conds, err := b.parseMultiple(operand)
+			if err != nil {
+				return nil, err
+			}
+			return Not{And(conds)}, nil
+		}
+	default:
+		{
+			return nil, errors.New("invalid operand, $not expects map or array")
 		}
 	}
 }
 
-func (b *builderContext) parseMultiple(operand interface{}) ([]sq.Sqlizer, error) {
+func (b *builderContext) parseMultiple(operand interface{}) ([]Node, error) {
 	rv := reflect.ValueOf(operand)
 	kind := rv.Kind()
 	switch kind {
 	case reflect.Array:
 		{
-			conds := []sq.Sqlizer{}
+			conds := []Node{}
 			length := rv.Len()
 			for i := 0; i < length; i++ {
 				elem := rv.Index(i)
@@ -127,7 +149,7 @@ func (b *builderContext) parseMultiple(operand interface{}) ([]sq.Sqlizer, error
 		}
 	case reflect.Slice:
 		{
-			conds := []sq.Sqlizer{}
+			conds := []Node{}
 			length := rv.Len()
 			for i := 0; i < length; i++ {
 				elem := rv.Index(i)
@@ -146,106 +168,88 @@ func (b *builderContext) parseMultiple(operand interface{}) ([]sq.Sqlizer, error
 	}
 }
 
-func (b *builderContext) parseElem(elem reflect.Value) (sq.Sqlizer, error) {
-	kind := elem.Kind()
-	switch kind {
-	case reflect.Map:
-		{
-			m := map[string]interface{}{}
-			iter := elem.MapRange()
-			for iter.Next() {
-				key := iter.Key()
-				value := iter.Value()
-				keyKind := key.Kind()
-				if keyKind != reflect.String {
-					return nil, errors.New("key must be string")
-				}
-				m[key.String()] = value.Interface()
-			}
-			nb := b.inherit()
-			nb.rel = OpAnd
-			fmt.Printf("%+v, %+v\n", nb, *b)
-			return nb.parseWhere(m)
-		}
-	default:
-		{
-			return nil, errors.New("unimplemented")
-		}
+func (b *builderContext) parseElem(elem reflect.Value) (Node, error) {
+	if elem.Kind() == reflect.Interface {
+		elem = elem.Elem()
 	}
-
+	if !elem.IsValid() {
+		return nil, errors.New("unimplemented")
+	}
+	where := elem.Interface()
+	if _, ok, _ := mapEntries(where); !ok {
+		return nil, errors.New("unimplemented")
+	}
+	nb := b.inherit()
+	nb.rel = OpAnd
+	return nb.parseWhere(where)
 }
 
-func (b *builderContext) parseKeyValuePair(op Op, elem interface{}) ([]sq.Sqlizer, error) {
-	rv := reflect.ValueOf(elem)
-	kind := rv.Kind()
-	switch kind {
-	case reflect.Map:
-		{
-			m := map[string]interface{}{}
-			iter := rv.MapRange()
-			for iter.Next() {
-				key := iter.Key()
-				value := iter.Value()
-				keyKind := key.Kind()
-				if keyKind != reflect.String {
-					return nil, errors.New("key must be string")
-				}
-				attr := key.String()
-				alias := b.toFullName(b.tableName, attr)
-				m[alias] = value.Interface()
-			}
-			return wrapOp(op, m)
-		}
-	default:
-		{
-			return nil, errors.New("invalid operand")
+func (b *builderContext) parseKeyValuePair(op Op, elem interface{}) ([]Node, error) {
+	entries, ok, err := mapEntries(elem)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid operand")
+	}
+	conds := []Node{}
+	for _, e := range entries {
+		cond, err := b.compare(e.key, op, e.value)
+		if err != nil {
+			return nil, err
 		}
+		conds = append(conds, cond)
 	}
+	return conds, nil
 }
 
-func (b *builderContext) parseVal(key string, where interface{}) ([]sq.Sqlizer, error) {
-	rv := reflect.ValueOf(where)
-	switch rv.Kind() {
-	case reflect.Map:
-		{
-			conds := []sq.Sqlizer{}
-			iter := rv.MapRange()
-			for iter.Next() {
-				k := iter.Key()
-				switch k.Kind() {
-				case reflect.String:
-					{
-						op, err := b.toOperator(k.String())
-						if err != nil {
-							return nil, err
-						}
-						operand := map[string]interface{}{
-							key: iter.Value().Interface(),
-						}
-						parts, err := b.parseOp(op, operand)
-						if err != nil {
-							return nil, err
-						}
-						conds = append(conds, parts...)
-					}
-				default:
-					{
-						return nil, errors.New("invalid key type")
-					}
-				}
-			}
-			return conds, nil
+func (b *builderContext) parseVal(key string, where interface{}) ([]Node, error) {
+	entries, ok, err := mapEntries(where)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		cond, err := b.compare(key, OpEq, where)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		{
-			alias := b.toFullName(b.tableName, key)
-			return []sq.Sqlizer{sq.Eq{alias: where}}, nil
+		return []Node{cond}, nil
+	}
+	conds := []Node{}
+	for _, e := range entries {
+		op, err := b.toOperator(e.key)
+		if err != nil {
+			return nil, err
 		}
+		operand := map[string]interface{}{
+			key: e.value,
+		}
+		parts, err := b.parseOp(op, operand)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, parts...)
+	}
+	return conds, nil
+}
+
+// compare build a Compare on the current table, rendering it once so that
+// invalid operands are reported by Parse rather than later by Render
+func (b *builderContext) compare(field string, op Op, value interface{}) (Compare, error) {
+	cond := Compare{
+		Table: b.tableName,
+		Field: field,
+		Op:    op,
+		Value: value,
+	}
+	if _, err := b.render(cond); err != nil {
+		return Compare{}, err
 	}
+	return cond, nil
 }
 
 func (b *builderContext) quote(name string) string {
-	return fmt.Sprintf("%s%s%s", b.builder.config.Quote, name, b.builder.config.Quote)
+	return b.builder.dialect().QuoteIdent(name)
 }
 
 func (b *builderContext) toOperator(str string) (Op, error) {
@@ -17,7 +17,7 @@ func TestBuilderContext_parseKeyValuePair(t *testing.T) {
 		name    string
 		b       *builderContext
 		args    args
-		want    []sq.Sqlizer
+		want    []Node
 		wantErr bool
 	}{
 		// TODO: Add test cases.
@@ -34,9 +34,9 @@ func TestBuilderContext_parseKeyValuePair(t *testing.T) {
 					"b": 2,
 				},
 			},
-			want: []sq.Sqlizer{
-				sq.Eq{`table1.a`: 1},
-				sq.Eq{`table1.b`: 2},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1},
+				Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2},
 			},
 		},
 	}
@@ -63,7 +63,7 @@ func TestBuilderContext_parseElem(t *testing.T) {
 		name    string
 		b       *builderContext
 		args    args
-		want    sq.Sqlizer
+		want    Node
 		wantErr bool
 	}{
 		// TODO: Add test cases.
@@ -79,9 +79,9 @@ func TestBuilderContext_parseElem(t *testing.T) {
 					"b": 2,
 				}),
 			},
-			want: sq.And{
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.a": 1}}),
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.b": 2}}),
+			want: And{
+				And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}},
+				And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}},
 			},
 		},
 	}
@@ -92,7 +92,7 @@ func TestBuilderContext_parseElem(t *testing.T) {
 				t.Errorf("builderContext.parseElem() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !andCompare(got.(sq.And), tt.want.(sq.And)) {
+			if !reflect.DeepEqual(got, tt.want) {
 				diff := deep.Equal(got, tt.want)
 				t.Errorf("builderContext.parseElem() = %v, want %v, diff = %v", got, tt.want, diff)
 			}
@@ -111,7 +111,7 @@ func TestBuilderContext_parseVal(t *testing.T) {
 		name    string
 		b       *builderContext
 		args    args
-		want    []sq.Sqlizer
+		want    []Node
 		wantErr bool
 	}{
 		// TODO: Add test cases.
@@ -125,8 +125,8 @@ func TestBuilderContext_parseVal(t *testing.T) {
 				key:   "a",
 				where: 1,
 			},
-			want: []sq.Sqlizer{
-				sq.Eq{"table1.a": 1},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1},
 			},
 		},
 		{
@@ -142,9 +142,25 @@ func TestBuilderContext_parseVal(t *testing.T) {
 					"$gt": 2,
 				},
 			},
-			want: []sq.Sqlizer{
-				sq.Lt{"table1.a": 1},
-				sq.Gt{"table1.a": 2},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpGt, Value: 2},
+				Compare{Table: "table1", Field: "a", Op: OpLt, Value: 1},
+			},
+		},
+		{
+			name: "with $in",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				key: "a",
+				where: map[string]interface{}{
+					"$in": []string{"x", "y"},
+				},
+			},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpIn, Value: []string{"x", "y"}},
 			},
 		},
 	}
@@ -172,7 +188,7 @@ func TestBuilderContext_parseOp(t *testing.T) {
 		name    string
 		b       *builderContext
 		args    args
-		want    []sq.Sqlizer
+		want    []Node
 		wantErr bool
 	}{
 		// TODO: Add test cases.
@@ -189,11 +205,147 @@ func TestBuilderContext_parseOp(t *testing.T) {
 					"b": 2,
 				},
 			},
-			want: []sq.Sqlizer{
-				sq.Eq{"table1.a": 1},
-				sq.Eq{"table1.b": 2},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1},
+				Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2},
+			},
+		},
+		{
+			name: "$in",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpIn,
+				operand: map[string]interface{}{
+					"a": [2]int{1, 2},
+				},
+			},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpIn, Value: [2]int{1, 2}},
+			},
+		},
+		{
+			name: "$notIn",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpNotIn,
+				operand: map[string]interface{}{
+					"a": []interface{}{},
+				},
+			},
+			want: []Node{
+				Compare{Table: "table1", Field: "a", Op: OpNotIn, Value: []interface{}{}},
 			},
 		},
+		{
+			name: "$not map",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpNot,
+				operand: map[string]interface{}{
+					"a": map[string]interface{}{"$gt": 1},
+				},
+			},
+			want: []Node{
+				Not{And{And{Compare{Table: "table1", Field: "a", Op: OpGt, Value: 1}}}},
+			},
+		},
+		{
+			name: "$not array",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpNot,
+				operand: []interface{}{
+					map[string]interface{}{"a": 1},
+					map[string]interface{}{"b": 2},
+				},
+			},
+			want: []Node{
+				Not{And{
+					And{And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}}},
+					And{And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}}},
+				}},
+			},
+		},
+		{
+			name: "$or",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpOr,
+				operand: []interface{}{
+					map[string]interface{}{"a": 1},
+					map[string]interface{}{"b": 2},
+				},
+			},
+			want: []Node{
+				Or{
+					And{And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}}},
+					And{And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}}},
+				},
+			},
+		},
+		{
+			name: "$not $or",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpNot,
+				operand: map[string]interface{}{
+					"$or": []interface{}{
+						map[string]interface{}{"a": 1},
+						map[string]interface{}{"b": 2},
+					},
+				},
+			},
+			want: []Node{
+				Not{And{And{Or{
+					And{And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}}},
+					And{And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}}},
+				}}}},
+			},
+		},
+		{
+			name: "$not scalar",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op:      OpNot,
+				operand: 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "$in with scalar operand",
+			b: &builderContext{
+				builder:   builder,
+				tableName: "table1",
+			},
+			args: args{
+				op: OpIn,
+				operand: map[string]interface{}{
+					"a": 1,
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -218,7 +370,7 @@ func Test_builderContext_parseWhere(t *testing.T) {
 		name    string
 		b       *builderContext
 		args    args
-		want    sq.Sqlizer
+		want    Node
 		wantErr bool
 	}{
 		// TODO: Add test cases.
@@ -235,9 +387,9 @@ func Test_builderContext_parseWhere(t *testing.T) {
 					"b": 2,
 				},
 			},
-			want: sq.And{
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.a": 1}}),
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.b": 2}}),
+			want: And{
+				And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}},
+				And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}},
 			},
 		},
 		{
@@ -253,9 +405,9 @@ func Test_builderContext_parseWhere(t *testing.T) {
 					"b": 2,
 				},
 			},
-			want: sq.Or{
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.a": 1}}),
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.b": 2}}),
+			want: Or{
+				And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}},
+				And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}},
 			},
 		},
 		{
@@ -274,9 +426,9 @@ func Test_builderContext_parseWhere(t *testing.T) {
 					"b": 2,
 				},
 			},
-			want: sq.And{
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.a": 1}, sq.Eq{"table1.b": 2}}),
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.b": 2}}),
+			want: And{
+				And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}, Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}},
+				And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}},
 			},
 		},
 		{
@@ -295,9 +447,9 @@ func Test_builderContext_parseWhere(t *testing.T) {
 					"b": 2,
 				},
 			},
-			want: sq.And{
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.a": 1}, sq.Gt{"table1.a": 2}}),
-				sq.And([]sq.Sqlizer{sq.Eq{"table1.b": 2}}),
+			want: And{
+				And{Compare{Table: "table1", Field: "a", Op: OpEq, Value: 1}, Compare{Table: "table1", Field: "a", Op: OpGt, Value: 2}},
+				And{Compare{Table: "table1", Field: "b", Op: OpEq, Value: 2}},
 			},
 		},
 	}
@@ -308,9 +460,43 @@ func Test_builderContext_parseWhere(t *testing.T) {
 				t.Errorf("builderContext.parseWhere() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !sqCompare(got, tt.want) {
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("builderContext.parseWhere() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_notExpr_ToSql(t *testing.T) {
+	tests := []struct {
+		name     string
+		e        notExpr
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "or",
+			e:        notExpr{sq.Or{sq.Eq{"a": 1}, sq.Eq{"b": 2}}},
+			wantSQL:  "(NOT ((a = ? OR b = ?)))",
+			wantArgs: []interface{}{1, 2},
+		},
+		{
+			name:     "empty",
+			e:        notExpr{sq.And{}},
+			wantSQL:  "(NOT ((1=1)))",
+			wantArgs: []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.e.ToSql()
+			if err != nil {
+				t.Errorf("notExpr.ToSql() error = %v", err)
+				return
+			}
+			if sql != tt.wantSQL || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("notExpr.ToSql() = %v %v, want %v %v", sql, args, tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+}
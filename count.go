@@ -0,0 +1,79 @@
+package goquery
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// BuildCount build a query counting the rows of the main table matching
+// filter. Order, pagination and attributes are ignored. Only includes that
+// filter the main table are joined: required includes and the includes on
+// their path. Rows are counted by distinct primary key when one of them
+// can match more than one row
+func (b *Builder) BuildCount(filter Filter) (sq.Sqlizer, error) {
+	filter.Attributes = nil
+	filter.Order = nil
+	filter.Offset = nil
+	filter.Limit = nil
+	filter.Keyset = false
+	filter.After = ""
+	filter.Before = ""
+	filter.SubQuery = false
+	query, err := b.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.validate(query); err != nil {
+		return nil, err
+	}
+	ctx := builderContext{
+		builder: b,
+		rel:     OpAnd,
+	}
+	from, tableAlias, err := ctx.buildFrom(filter.From)
+	if err != nil {
+		return nil, err
+	}
+	ctx.tableName = tableAlias
+
+	includes := countIncludes(query.Include)
+	count := "COUNT(*)"
+	for _, iq := range flattenIncludes(includes) {
+		if iq.toMany() {
+			count = fmt.Sprintf("COUNT(DISTINCT %s)", ctx.toFullName(tableAlias, b.primaryKey()))
+			break
+		}
+	}
+	bs := sq.Select(count).From(from)
+	if len(includes) > 0 {
+		if bs, err = ctx.addJoins(bs, tableAlias, includes); err != nil {
+			return nil, err
+		}
+	}
+	wheres, err := ctx.render(query.Where)
+	if err != nil {
+		return nil, err
+	}
+	bs = bs.Where(wheres)
+	return bs.PlaceholderFormat(b.dialect().Placeholder), nil
+}
+
+// countIncludes the joined includes that can filter the main table, which
+// are the required includes and their ancestors
+func countIncludes(includes []*IncludeQuery) []*IncludeQuery {
+	list := []*IncludeQuery{}
+	for _, iq := range includes {
+		if iq.Include.Separate {
+			continue
+		}
+		children := countIncludes(iq.Children)
+		if !iq.Include.Required && len(children) == 0 {
+			continue
+		}
+		pruned := *iq
+		pruned.Children = children
+		list = append(list, &pruned)
+	}
+	return list
+}
@@ -0,0 +1,98 @@
+package goquery
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_BuildCount(t *testing.T) {
+	builder, _ := New(BuilderConfig{Dialect: Postgres, Models: Models{
+		"authors": Model{
+			Associations: map[string]Association{
+				"profile": {Kind: HasOne, Table: "profiles", ForeignKey: "author_id"},
+				"posts":   {Kind: HasMany, Table: "posts", ForeignKey: "author_id"},
+			},
+		},
+		"profiles": Model{
+			Associations: map[string]Association{
+				"avatar": {Kind: HasOne, Table: "images", ForeignKey: "profile_id"},
+			},
+		},
+	}})
+	limit := uint64(20)
+	tests := []struct {
+		name     string
+		filter   Filter
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name: "order, pagination and attributes dropped",
+			filter: Filter{
+				From:       "authors",
+				Attributes: []interface{}{"name"},
+				Where:      map[string]interface{}{"active": true},
+				Order:      []interface{}{"-name"},
+				Limit:      &limit,
+			},
+			wantSQL:  `SELECT COUNT(*) FROM "authors" WHERE (("authors"."active" = $1))`,
+			wantArgs: []interface{}{true},
+		},
+		{
+			name: "optional includes omitted",
+			filter: Filter{
+				From: "authors",
+				Include: []Include{
+					{Association: "profile"},
+					{Association: "posts", Where: map[string]interface{}{"draft": false}},
+				},
+			},
+			wantSQL: `SELECT COUNT(*) FROM "authors" WHERE (1=1)`,
+		},
+		{
+			name: "required hasMany counts distinct",
+			filter: Filter{
+				From:    "authors",
+				Include: []Include{{Association: "posts", Required: true, Where: map[string]interface{}{"draft": false}}},
+			},
+			wantSQL:  `SELECT COUNT(DISTINCT "authors"."id") FROM "authors" JOIN "posts" ON "authors"."id" = "posts"."author_id" WHERE (("posts"."draft" = $1)) AND (1=1)`,
+			wantArgs: []interface{}{false},
+		},
+		{
+			name: "required below optional",
+			filter: Filter{
+				From: "authors",
+				Include: []Include{{Association: "profile", Include: []Include{
+					{Association: "avatar", Required: true},
+				}}},
+			},
+			wantSQL: `SELECT COUNT(*) FROM "authors" JOIN "profiles" AS "profile" ON "authors"."id" = "profile"."author_id" ` +
+				`JOIN "images" AS "profile__avatar" ON "profile"."id" = "profile__avatar"."profile_id" WHERE (1=1)`,
+		},
+		{
+			name:    "unknown association",
+			filter:  Filter{From: "authors", Include: []Include{{Association: "comments"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.BuildCount(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.BuildCount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			sql, args, _ := im.ToSql()
+			if sql != tt.wantSQL {
+				t.Errorf("Builder.BuildCount() sql = %v, want %v", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Builder.BuildCount() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package goquery
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lann/builder"
+)
+
+// PaginationStyle how LIMIT and OFFSET are rendered
+type PaginationStyle int
+
+const (
+	// PaginationLimitOffset `LIMIT n OFFSET m`
+	PaginationLimitOffset PaginationStyle = iota
+	// PaginationOffsetFetch `OFFSET m ROWS FETCH NEXT n ROWS ONLY`
+	PaginationOffsetFetch
+)
+
+// Dialect database specific rendering rules
+type Dialect struct {
+	Name string
+	// Placeholder bind parameter format
+	Placeholder sq.PlaceholderFormat
+	// QuoteOpen and QuoteClose surround identifiers, QuoteClose is doubled
+	// when it appears inside an identifier
+	QuoteOpen  string
+	QuoteClose string
+	Pagination PaginationStyle
+	// PaginationNeedsOrder OFFSET ... FETCH is only valid after ORDER BY
+	PaginationNeedsOrder bool
+	// ILike database has a native ILIKE operator, otherwise both sides are
+	// lowered
+	ILike bool
+	// BackslashEscapes string literals treat backslash as an escape character
+	BackslashEscapes bool
+	// RowValues row value comparisons like `(a, b) > (?, ?)` are supported
+	RowValues bool
+	// NullsOrder `NULLS FIRST` and `NULLS LAST` are supported, otherwise
+	// they are emulated
+	NullsOrder bool
+	// QuoteCollation collation names are quoted like identifiers
+	QuoteCollation bool
+	// BareTableAlias table aliases follow the table without AS
+	BareTableAlias bool
+	// NumericBool booleans are stored as 0 and 1 and `IS TRUE` / `IS FALSE`
+	// are not supported
+	NumericBool bool
+}
+
+var (
+	// Postgres PostgreSQL dialect
+	Postgres = &Dialect{
+		Name:           "postgres",
+		Placeholder:    sq.Dollar,
+		QuoteOpen:      `"`,
+		QuoteClose:     `"`,
+		Pagination:     PaginationLimitOffset,
+		ILike:          true,
+		RowValues:      true,
+		NullsOrder:     true,
+		QuoteCollation: true,
+	}
+	// MySQL MySQL / MariaDB dialect
+	MySQL = &Dialect{
+		Name:             "mysql",
+		Placeholder:      sq.Question,
+		QuoteOpen:        "`",
+		QuoteClose:       "`",
+		Pagination:       PaginationLimitOffset,
+		BackslashEscapes: true,
+		RowValues:        true,
+	}
+	// SQLite SQLite dialect
+	SQLite = &Dialect{
+		Name:        "sqlite",
+		Placeholder: sq.Question,
+		QuoteOpen:   `"`,
+		QuoteClose:  `"`,
+		Pagination:  PaginationLimitOffset,
+		RowValues:   true,
+		NullsOrder:  true,
+	}
+	// SQLServer Microsoft SQL Server dialect
+	SQLServer = &Dialect{
+		Name:                 "sqlserver",
+		Placeholder:          AtP,
+		QuoteOpen:            "[",
+		QuoteClose:           "]",
+		Pagination:           PaginationOffsetFetch,
+		PaginationNeedsOrder: true,
+		NumericBool:          true,
+	}
+	// Oracle Oracle 12c+ dialect
+	Oracle = &Dialect{
+		Name:           "oracle",
+		Placeholder:    sq.Colon,
+		QuoteOpen:      `"`,
+		QuoteClose:     `"`,
+		Pagination:     PaginationOffsetFetch,
+		NullsOrder:     true,
+		BareTableAlias: true,
+		NumericBool:    true,
+	}
+)
+
+// AtP placeholder format replacing `?` with `@p1`, `@p2`, ...
+var AtP = atPFormat{}
+
+type atPFormat struct{}
+
+func (atPFormat) ReplacePlaceholders(sql string) (string, error) {
+	buf := &bytes.Buffer{}
+	i := 0
+	for {
+		p := strings.Index(sql, "?")
+		if p == -1 {
+			break
+		}
+		if len(sql[p:]) > 1 && sql[p:p+2] == "??" {
+			// escape ?? => ?
+			buf.WriteString(sql[:p+1])
+			sql = sql[p+2:]
+			continue
+		}
+		i++
+		buf.WriteString(sql[:p])
+		fmt.Fprintf(buf, "@p%d", i)
+		sql = sql[p+1:]
+	}
+	buf.WriteString(sql)
+	return buf.String(), nil
+}
+
+// QuoteIdent quote a single identifier
+func (d *Dialect) QuoteIdent(name string) string {
+	if d.QuoteClose != "" {
+		name = strings.Replace(name, d.QuoteClose, d.QuoteClose+d.QuoteClose, -1)
+	}
+	return d.QuoteOpen + name + d.QuoteClose
+}
+
+// aliasTable alias a table or a parenthesized derived table
+func (d *Dialect) aliasTable(table string, alias string) string {
+	if d.BareTableAlias {
+		return fmt.Sprintf("%s %s", table, alias)
+	}
+	return fmt.Sprintf("%s AS %s", table, alias)
+}
+
+// fromSelect like SelectBuilder.FromSelect, honoring BareTableAlias
+func (d *Dialect) fromSelect(bs sq.SelectBuilder, from sq.SelectBuilder, alias string) sq.SelectBuilder {
+	return builder.Set(bs, "From", derivedTable{dialect: d, from: from, alias: alias}).(sq.SelectBuilder)
+}
+
+// derivedTable aliased subquery in a FROM clause
+type derivedTable struct {
+	dialect *Dialect
+	from    sq.SelectBuilder
+	alias   string
+}
+
+func (t derivedTable) ToSql() (string, []interface{}, error) {
+	sql, args, err := t.from.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	return t.dialect.aliasTable("("+sql+")", t.alias), args, nil
+}
+
+// likeEscapeLiteral the SQL string literal of likeEscape
+func (d *Dialect) likeEscapeLiteral() string {
+	if d.BackslashEscapes {
+		return fmt.Sprintf("'%s%s'", likeEscape, likeEscape)
+	}
+	return fmt.Sprintf("'%s'", likeEscape)
+}
+
+// paginate apply limit and offset, a nil limit means no limit. Where FETCH
+// NEXT is used, a zero limit is rendered as a false condition
+func (d *Dialect) paginate(bs sq.SelectBuilder, limit *uint64, offset uint64, ordered bool) sq.SelectBuilder {
+	switch d.Pagination {
+	case PaginationOffsetFetch:
+		{
+			if limit == nil && offset == 0 {
+				return bs
+			}
+			if limit != nil && *limit == 0 {
+				// FETCH NEXT needs a positive row count
+				bs = bs.Where("(1=0)")
+				limit = nil
+			}
+			if d.PaginationNeedsOrder && !ordered {
+				bs = bs.OrderBy("(SELECT NULL)")
+			}
+			clause := fmt.Sprintf("OFFSET %d ROWS", offset)
+			if limit != nil {
+				clause = fmt.Sprintf("%s FETCH NEXT %d ROWS ONLY", clause, *limit)
+			}
+			return bs.Suffix(clause)
+		}
+	default:
+		{
+			if limit != nil {
+				bs = bs.Limit(*limit)
+			}
+			if offset != 0 {
+				bs = bs.Offset(offset)
+			}
+			return bs
+		}
+	}
+}
+
+// dialect the configured dialect, or a generic one built from
+// BuilderConfig.Quote
+func (b *Builder) dialect() *Dialect {
+	if b.config.Dialect != nil {
+		return b.config.Dialect
+	}
+	return &Dialect{
+		Name:        "generic",
+		Placeholder: sq.Question,
+		QuoteOpen:   b.config.Quote,
+		QuoteClose:  b.config.Quote,
+		Pagination:  PaginationLimitOffset,
+	}
+}
@@ -0,0 +1,115 @@
+package goquery
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// DB runs queries, satisfied by *sql.DB, *sql.Tx and *sql.Conn
+type DB interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Executor run filters against a database. Queries are rendered with the
+// placeholders of the builder dialect
+type Executor struct {
+	builder *Builder
+	db      DB
+}
+
+// NewExecutor new executor running the queries of builder on db
+func NewExecutor(builder *Builder, db DB) *Executor {
+	return &Executor{builder: builder, db: db}
+}
+
+// dbQuerier Querier running queries on a DB with a context
+type dbQuerier struct {
+	ctx context.Context
+	db  DB
+}
+
+func (q dbQuerier) QueryRows(query sq.Sqlizer) ([]map[string]interface{}, error) {
+	str, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.db.QueryContext(q.ctx, str, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// FindAll rows matching filter, with the joined includes nested like
+// Builder.Hydrate and the separate includes added like Plan.Run
+func (e *Executor) FindAll(ctx context.Context, filter Filter) ([]map[string]interface{}, error) {
+	plan, err := e.builder.BuildPlan(filter)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Run(dbQuerier{ctx: ctx, db: e.db})
+}
+
+// FindOne first row matching filter, sql.ErrNoRows when there is none
+func (e *Executor) FindOne(ctx context.Context, filter Filter) (map[string]interface{}, error) {
+	limit := uint64(1)
+	filter.Limit = &limit
+	rows, err := e.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return rows[0], nil
+}
+
+// Count number of rows of the main table matching filter, ignoring its
+// order and pagination, see Builder.BuildCount
+func (e *Executor) Count(ctx context.Context, filter Filter) (uint64, error) {
+	query, err := e.builder.BuildCount(filter)
+	if err != nil {
+		return 0, err
+	}
+	str, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	return e.count(ctx, str, args)
+}
+
+func (e *Executor) count(ctx context.Context, query string, args []interface{}) (uint64, error) {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var count uint64
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, sql.ErrNoRows
+	}
+	if err := rows.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, rows.Close()
+}
+
+// FindAndCount page of rows matching filter and the number of rows
+// matching filter regardless of pagination
+func (e *Executor) FindAndCount(ctx context.Context, filter Filter) ([]map[string]interface{}, uint64, error) {
+	rows, err := e.FindAll(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := e.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, count, nil
+}
@@ -0,0 +1,144 @@
+//go:build cgo
+// +build cgo
+
+package goquery
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDB in-memory database with a few authors, posts and comments,
+// the driver needs cgo
+func sqliteDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT, active BOOLEAN)`,
+		`CREATE TABLE profiles (id INTEGER PRIMARY KEY, author_id INTEGER, bio TEXT)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER, title TEXT, draft BOOLEAN)`,
+		`CREATE TABLE comments (id INTEGER PRIMARY KEY, post_id INTEGER, body TEXT)`,
+		`INSERT INTO authors VALUES (1, 'ann', 1), (2, 'bob', 1), (3, 'cid', 0), (4, 'dan', 1)`,
+		`INSERT INTO profiles VALUES (1, 1, 'hi')`,
+		`INSERT INTO posts VALUES (10, 1, 'first', 0), (11, 1, 'second', 0), (12, 2, 'third', 1), (13, 4, 'fourth', 0)`,
+		`INSERT INTO comments VALUES (100, 10, 'nice'), (101, 10, 'meh'), (102, 13, 'late')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestExecutor_sqlite(t *testing.T) {
+	builder, _ := New(BuilderConfig{Dialect: SQLite, Models: Models{
+		"authors": Model{
+			Associations: map[string]Association{
+				"profile": {Kind: HasOne, Table: "profiles", ForeignKey: "author_id"},
+				"posts":   {Kind: HasMany, Table: "posts", ForeignKey: "author_id"},
+			},
+		},
+		"posts": Model{
+			Associations: map[string]Association{
+				"comments": {Kind: HasMany, Table: "comments", ForeignKey: "post_id"},
+			},
+		},
+	}})
+	db := sqliteDB(t)
+	defer db.Close()
+	executor := NewExecutor(builder, db)
+	limit := uint64(2)
+	rows, count, err := executor.FindAndCount(context.Background(), Filter{
+		From:       "authors",
+		Attributes: []interface{}{"id", "name"},
+		Where:      map[string]interface{}{"active": true},
+		Include: []Include{
+			{Association: "profile", Attributes: []interface{}{"bio"}},
+			{
+				Association: "posts",
+				Attributes:  []interface{}{"id", "title"},
+				Where:       map[string]interface{}{"draft": false},
+				Separate:    true,
+				Include: []Include{
+					{Association: "comments", Attributes: []interface{}{"id", "body"}, Separate: true},
+				},
+			},
+		},
+		Order: []interface{}{"name"},
+		Limit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("Executor.FindAndCount() error = %v", err)
+	}
+	want := []map[string]interface{}{
+		{
+			"id":      int64(1),
+			"name":    "ann",
+			"profile": map[string]interface{}{"bio": "hi"},
+			"posts": []map[string]interface{}{
+				{"id": int64(10), "title": "first", "comments": []map[string]interface{}{
+					{"id": int64(100), "body": "nice"},
+					{"id": int64(101), "body": "meh"},
+				}},
+				{"id": int64(11), "title": "second", "comments": []map[string]interface{}{}},
+			},
+		},
+		{
+			"id":      int64(2),
+			"name":    "bob",
+			"profile": nil,
+			"posts":   []map[string]interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Executor.FindAndCount() rows = %v, want %v", rows, want)
+	}
+	if count != 3 {
+		t.Errorf("Executor.FindAndCount() count = %v, want 3", count)
+	}
+	rows, err = executor.FindAll(context.Background(), Filter{
+		From:       "authors",
+		Attributes: []interface{}{"id"},
+		Include: []Include{{
+			Association: "posts",
+			Attributes:  []interface{}{"id"},
+			Where:       map[string]interface{}{"draft": false, "id": map[string]interface{}{"$gt": 10}},
+			Required:    true,
+			Include:     []Include{{Association: "comments", Attributes: []interface{}{"body"}}},
+		}},
+		Order: []interface{}{"id"},
+		Limit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("Executor.FindAll() error = %v", err)
+	}
+	want = []map[string]interface{}{
+		{"id": int64(1), "posts": []map[string]interface{}{
+			{"id": int64(11), "comments": []map[string]interface{}{}},
+		}},
+		{"id": int64(4), "posts": []map[string]interface{}{
+			{"id": int64(13), "comments": []map[string]interface{}{{"body": "late"}}},
+		}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Executor.FindAll() rows = %v, want %v", rows, want)
+	}
+	row, err := executor.FindOne(context.Background(), Filter{
+		From:  "authors",
+		Where: map[string]interface{}{"name": "bob"},
+	})
+	if err != nil {
+		t.Fatalf("Executor.FindOne() error = %v", err)
+	}
+	if want := map[string]interface{}{"id": int64(2), "name": "bob", "active": true}; !reflect.DeepEqual(row, want) {
+		t.Errorf("Executor.FindOne() = %v, want %v", row, want)
+	}
+}
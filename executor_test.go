@@ -0,0 +1,108 @@
+package goquery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestExecutor_FindAndCount(t *testing.T) {
+	builder, _ := New(BuilderConfig{Dialect: Postgres, Models: Models{
+		"authors": Model{
+			Associations: map[string]Association{
+				"profile": {Kind: HasOne, Table: "profiles", ForeignKey: "author_id"},
+				"posts":   {Kind: HasMany, Table: "posts", ForeignKey: "author_id"},
+			},
+		},
+	}})
+	db, log := stubDB(t,
+		&stubResult{
+			columns: []string{"id", "name", "profile.bio"},
+			rows: [][]driver.Value{
+				{int64(1), "ann", "hi"},
+				{int64(2), "bob", nil},
+			},
+		},
+		&stubResult{
+			columns: []string{"id", "title", "__parent"},
+			rows:    [][]driver.Value{{int64(10), "first", int64(2)}},
+		},
+		&stubResult{
+			columns: []string{"count"},
+			rows:    [][]driver.Value{{int64(7)}},
+		},
+	)
+	defer db.Close()
+	limit := uint64(2)
+	rows, count, err := NewExecutor(builder, db).FindAndCount(context.Background(), Filter{
+		From:       "authors",
+		Attributes: []interface{}{"id", "name"},
+		Where:      map[string]interface{}{"active": true},
+		Include: []Include{
+			{Association: "profile", Attributes: []interface{}{"bio"}},
+			{Association: "posts", Attributes: []interface{}{"id", "title"}, Separate: true},
+		},
+		Order: []interface{}{"name"},
+		Limit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("Executor.FindAndCount() error = %v", err)
+	}
+	wantQueries := []string{
+		`SELECT "authors"."id" AS "id", "authors"."name" AS "name", "profile"."bio" AS "profile.bio" FROM "authors" LEFT JOIN "profiles" AS "profile" ON "authors"."id" = "profile"."author_id" WHERE (("authors"."active" = $1)) ORDER BY "authors"."name" ASC LIMIT 2`,
+		`SELECT "posts"."id" AS "id", "posts"."title" AS "title", "posts"."author_id" AS "__parent" FROM "posts" WHERE "posts"."author_id" IN ($1,$2)`,
+		`SELECT COUNT(*) FROM "authors" WHERE (("authors"."active" = $1))`,
+	}
+	if !reflect.DeepEqual(log.queries, wantQueries) {
+		t.Errorf("Executor.FindAndCount() queries = %v, want %v", log.queries, wantQueries)
+	}
+	wantArgs := [][]driver.Value{{true}, {int64(1), int64(2)}, {true}}
+	if !reflect.DeepEqual(log.args, wantArgs) {
+		t.Errorf("Executor.FindAndCount() args = %v, want %v", log.args, wantArgs)
+	}
+	want := []map[string]interface{}{
+		{
+			"id":      int64(1),
+			"name":    "ann",
+			"profile": map[string]interface{}{"bio": "hi"},
+			"posts":   []map[string]interface{}{},
+		},
+		{
+			"id":      int64(2),
+			"name":    "bob",
+			"profile": nil,
+			"posts":   []map[string]interface{}{{"id": int64(10), "title": "first"}},
+		},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Executor.FindAndCount() rows = %v, want %v", rows, want)
+	}
+	if count != 7 {
+		t.Errorf("Executor.FindAndCount() count = %v, want 7", count)
+	}
+}
+
+func TestExecutor_FindOne(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	db, log := stubDB(t,
+		&stubResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(3)}}},
+		&stubResult{columns: []string{"id"}},
+	)
+	defer db.Close()
+	executor := NewExecutor(builder, db)
+	row, err := executor.FindOne(context.Background(), Filter{From: "posts"})
+	if err != nil {
+		t.Fatalf("Executor.FindOne() error = %v", err)
+	}
+	if !reflect.DeepEqual(row, map[string]interface{}{"id": int64(3)}) {
+		t.Errorf("Executor.FindOne() = %v", row)
+	}
+	if want := "SELECT * FROM posts WHERE (1=1) LIMIT 1"; log.queries[0] != want {
+		t.Errorf("Executor.FindOne() query = %v, want %v", log.queries[0], want)
+	}
+	if _, err := executor.FindOne(context.Background(), Filter{From: "posts"}); err != sql.ErrNoRows {
+		t.Errorf("Executor.FindOne() error = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -6,7 +6,11 @@ func (b *builderContext) buildFrom(from interface{}) (string, string, error) {
 	switch t := from.(type) {
 	case string:
 		{
-			return b.quote(t), t, nil
+			quoted, err := b.quoteTable(t)
+			if err != nil {
+				return "", "", err
+			}
+			return quoted, tableAlias(t), nil
 		}
 	default:
 		{
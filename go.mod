@@ -5,4 +5,6 @@ go 1.12
 require (
 	github.com/Masterminds/squirrel v1.1.0
 	github.com/go-test/deep v1.0.1
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0
+	github.com/mattn/go-sqlite3 v1.14.6
 )
@@ -2,35 +2,84 @@ package goquery
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 
 	sq "github.com/Masterminds/squirrel"
 )
 
 // IncludeThrough include with a joint table
 type IncludeThrough struct {
-	TableName  string
-	SourceKey  string
-	ForeignKey string
+	TableName  string `json:"tableName"`
+	SourceKey  string `json:"sourceKey"`
+	ForeignKey string `json:"foreignKey"`
+	// Where conditions on the joint table
+	Where map[string]interface{} `json:"where,omitempty"`
 }
 
-// Include join definition
+// Include join definition, either given by Association or by Table and
+// keys
 type Include struct {
-	Table      string
-	SourceKey  string
-	ForeignKey string
-	Through    *IncludeThrough
-	Where      map[string]interface{}
+	// Association name of an association of the model of the including
+	// table, see BuilderConfig.Models
+	Association string                 `json:"association,omitempty"`
+	Table       string                 `json:"table"`
+	SourceKey   string                 `json:"sourceKey"`
+	ForeignKey  string                 `json:"foreignKey"`
+	Through     *IncludeThrough        `json:"through,omitempty"`
+	Where       map[string]interface{} `json:"where,omitempty"`
+	// Include nested includes, joined against this include
+	Include []Include `json:"include,omitempty"`
+	// Attributes columns of the include to select, their result columns are
+	// named by include path, e.g. `comments.author.name`. Joined includes
+	// need attributes unless the schema lists the columns of their table
+	Attributes []interface{} `json:"attributes,omitempty"`
+	// As alias of the include, defaults to Association or Table
+	As string `json:"as,omitempty"`
+	// Required join with INNER JOIN instead of LEFT JOIN. Conditions of
+	// required includes filter the rows of the main table, conditions of
+	// optional includes only filter the included rows. A required include
+	// makes its joined parent required
+	Required bool `json:"required,omitempty"`
+	// Separate load the include with a follow-up query instead of a join,
+	// see Builder.BuildPlan
+	Separate bool `json:"separate,omitempty"`
+}
+
+// alias table alias the include is joined as, which qualifies its where
+// fields and the order terms referring to it
+func (i Include) alias() string {
+	if i.As != "" {
+		return i.As
+	}
+	if i.Association != "" {
+		return i.Association
+	}
+	return tableAlias(i.Table)
 }
 
 // Filter filter structure
 type Filter struct {
-	From       string
-	Where      map[string]interface{}
-	Attributes []interface{}
-	Include    []Include
-	Order      []string
-	Offset     *uint64
-	Limit      *uint64
+	From       string                 `json:"from"`
+	Where      map[string]interface{} `json:"where,omitempty"`
+	Attributes []interface{}          `json:"attributes,omitempty"`
+	Include    []Include              `json:"include,omitempty"`
+	Order      []interface{}          `json:"order,omitempty"`
+	Offset     *uint64                `json:"offset,omitempty"`
+	Limit      *uint64                `json:"limit,omitempty"`
+	// Keyset paginate by seeking past a cursor instead of with OFFSET,
+	// implied when After or Before is set
+	Keyset bool `json:"keyset,omitempty"`
+	// After cursor of the last row of the previous page, see Builder.Cursor
+	After string `json:"after,omitempty"`
+	// Before cursor of the first row of the current page, to get the
+	// previous page. Rows are returned in reverse order and have to be
+	// reversed by the caller
+	Before string `json:"before,omitempty"`
+	// SubQuery paginate the main table in a derived table and join the
+	// includes onto it, implied when the filter is paginated and joins a
+	// hasMany, belongsToMany or through include
+	SubQuery bool `json:"subQuery,omitempty"`
 }
 
 // Op operator type
@@ -57,6 +106,36 @@ const (
 	OpLte = "$lte"
 	// OpLike like
 	OpLike = "$like"
+	// OpILike case-insensitive like
+	OpILike = "$iLike"
+	// OpNotLike not like
+	OpNotLike = "$notLike"
+	// OpNotILike case-insensitive not like
+	OpNotILike = "$notILike"
+	// OpStartsWith has prefix, wildcards in the operand are matched literally
+	OpStartsWith = "$startsWith"
+	// OpEndsWith has suffix, wildcards in the operand are matched literally
+	OpEndsWith = "$endsWith"
+	// OpContains has substring, wildcards in the operand are matched literally
+	OpContains = "$contains"
+	// OpIn in list
+	OpIn = "$in"
+	// OpNotIn not in list
+	OpNotIn = "$notIn"
+	// OpIs `IS NULL`, `IS TRUE` or `IS FALSE`, the operand must be nil or bool
+	OpIs = "$is"
+	// OpIsNot `IS NOT NULL`, `IS NOT TRUE` or `IS NOT FALSE`
+	OpIsNot = "$isNot"
+	// OpIsNull `IS NULL` when the operand is true, `IS NOT NULL` when false
+	OpIsNull = "$isNull"
+	// OpNotNull `IS NOT NULL` when the operand is true, `IS NULL` when false
+	OpNotNull = "$notNull"
+	// OpBetween closed range `[from, to]`, the operand is a two element array
+	OpBetween = "$between"
+	// OpNotBetween outside of the closed range `[from, to]`
+	OpNotBetween = "$notBetween"
+	// OpRange half-open range `[from, to)`, the operand is a two element array
+	OpRange = "$range"
 )
 
 var defaultOpMapping = map[Op]string{
@@ -69,6 +148,25 @@ var defaultOpMapping = map[Op]string{
 	OpGte:   "$gte",
 	OpLt:    "$lt",
 	OpLte:   "$lte",
+	OpIn:    "$in",
+	OpNotIn: "$notIn",
+
+	OpIs:      "$is",
+	OpIsNot:   "$isNot",
+	OpIsNull:  "$isNull",
+	OpNotNull: "$notNull",
+
+	OpBetween:    "$between",
+	OpNotBetween: "$notBetween",
+	OpRange:      "$range",
+
+	OpLike:       "$like",
+	OpILike:      "$iLike",
+	OpNotLike:    "$notLike",
+	OpNotILike:   "$notILike",
+	OpStartsWith: "$startsWith",
+	OpEndsWith:   "$endsWith",
+	OpContains:   "$contains",
 }
 
 // Builder goquery builder struct
@@ -77,12 +175,36 @@ type Builder struct {
 	operators    map[Op]string
 	revOperators map[string]Op
 	config       BuilderConfig
+	models       Models
 }
 
 // BuilderConfig goquery builder config
 type BuilderConfig struct {
 	OperatorMapping map[string]string
-	Quote           string
+	// Quote identifier quote used on both sides when Dialect is nil
+	Quote string
+	// Dialect placeholder, quoting and pagination rules, see Postgres,
+	// MySQL, SQLite, SQLServer and Oracle
+	Dialect *Dialect
+	// PrimaryKey unique column appended to the order of keyset pagination,
+	// defaults to `id`
+	PrimaryKey string
+	// OrderColumns fields allowed in Filter.Order, any field when empty
+	OrderColumns []string
+	// Models associations that includes can refer to by name
+	Models Models
+	// MaxIncludeDepth deepest accepted nesting of includes, 5 when zero
+	MaxIncludeDepth int
+	// Schema tables and columns filters may refer to, anything when nil.
+	// Without attributes only the selectable columns are selected
+	Schema Schema
+	// Pagination default and maximum limit and offset
+	Pagination PaginationPolicy
+	// QueryParams parameter names used by ParseQuery
+	QueryParams QueryParams
+	// NullSafeNotEq make `$notEq` and `$notIn` also match rows where the
+	// column is NULL, like `IS DISTINCT FROM`
+	NullSafeNotEq bool
 }
 
 // New create new builder
@@ -103,15 +225,33 @@ func New(config BuilderConfig) (*Builder, error) {
 		revOperators: revOps,
 		config:       config,
 	}
+	models, err := config.Models.resolve(builder.primaryKey())
+	if err != nil {
+		return nil, err
+	}
+	builder.models = models
 	return builder, nil
 }
 
 // Build build new query from filter
 func (b *Builder) Build(filter Filter) (sq.Sqlizer, error) {
+	query, err := b.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+	return b.BuildQuery(query)
+}
+
+// BuildQuery build new query from a parsed filter
+func (b *Builder) BuildQuery(query *Query) (sq.Sqlizer, error) {
+	filter := query.Filter
 	ctx := builderContext{
 		builder: b,
 		rel:     OpAnd,
 	}
+	if err := b.validate(query); err != nil {
+		return nil, err
+	}
 	// build main table and alias
 	from, tableAlias, err := ctx.buildFrom(filter.From)
 	if err != nil {
@@ -119,42 +259,121 @@ func (b *Builder) Build(filter Filter) (sq.Sqlizer, error) {
 	}
 	ctx.tableName = tableAlias
 	// build fully qualified attributes
-	attributes, err := ctx.attrBuild(filter.Attributes, tableAlias)
+	attrs := filter.Attributes
+	if len(attrs) == 0 && b.config.Schema != nil {
+		for _, column := range b.config.Schema[filter.From].columns() {
+			attrs = append(attrs, column)
+		}
+	}
+	if len(attrs) > 0 {
+		// separate includes look up their parents by source key
+		for _, iq := range query.Include {
+			if iq.Include.Separate && !containsAttr(attrs, iq.Include.SourceKey) {
+				attrs = append(attrs, iq.Include.SourceKey)
+			}
+		}
+	}
+	attributes, err := ctx.attrBuild(attrs, tableAlias, len(joinedIncludes(query.Include)) > 0)
+	if err != nil {
+		return nil, err
+	}
+	includeAttrs, err := ctx.includeAttrs(query.Include, "")
 	if err != nil {
 		return nil, err
 	}
+	attributes = append(attributes, includeAttrs...)
 	bs := sq.Select(attributes...).From(from)
 
-	// build includes
-	if len(filter.Include) > 0 {
-		bs, err = ctx.addJoins(bs, tableAlias, filter.Include)
-		if err != nil {
-			return nil, err
-		}
+	// build wheres
+	wheres, err := ctx.render(query.Where)
+	if err != nil {
+		return nil, err
 	}
 
-	// build wheres
-	wheres, err := ctx.parseWhere(filter.Where)
+	// order
+	keyset := filter.Keyset || filter.After != "" || filter.Before != ""
+	var terms []OrderTerm
+	var seek sq.Sqlizer
+	if keyset {
+		terms, seek, err = ctx.keyset(filter)
+	} else {
+		terms, err = b.orderTerms(filter)
+	}
 	if err != nil {
 		return nil, err
 	}
-	bs = bs.Where(wheres)
 
-	// add order
-	if len(filter.Order) > 0 {
-		bs = bs.OrderBy(filter.Order...)
+	dialect := b.dialect()
+
+	// limit and offset
+	limit, offset, err := b.pagination(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	// add limit
-	if filter.Limit != nil && *(filter.Limit) != 0 {
-		bs = bs.Limit(*(filter.Limit))
+	if b.subQuery(query, limit, offset) {
+		// paginate the main table in a derived table and join the includes
+		// onto the page
+		for _, term := range terms {
+			if alias, _ := splitOrderField(term.Field); alias != "" && alias != tableAlias {
+				return nil, fmt.Errorf("order by %s: included columns cannot be ordered by in subquery mode", term.Field)
+			}
+		}
+		Inspect(query.Where, func(n Node) bool {
+			if c, ok := n.(Compare); ok && c.Table != tableAlias && err == nil {
+				err = fmt.Errorf("where %s.%s: included columns cannot be filtered by in subquery mode", c.Table, c.Field)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		inner := sq.Select(ctx.quote(tableAlias) + ".*").From(from).Where(wheres)
+		if seek != nil {
+			inner = inner.Where(seek)
+		}
+		for _, iq := range query.Include {
+			if iq.Include.Required && !iq.Include.Separate {
+				exists, err := ctx.exists(tableAlias, iq)
+				if err != nil {
+					return nil, err
+				}
+				inner = inner.Where(exists)
+			}
+		}
+		if limit != nil || offset != 0 {
+			// derived tables only take an order with pagination
+			if len(terms) > 0 {
+				inner = ctx.orderBy(inner, terms)
+			}
+			inner = dialect.paginate(inner, limit, offset, len(terms) > 0)
+		}
+		bs = dialect.fromSelect(sq.Select(attributes...), inner, ctx.quote(tableAlias))
+		if bs, err = ctx.addJoins(bs, tableAlias, query.Include); err != nil {
+			return nil, err
+		}
+		if len(terms) > 0 {
+			bs = ctx.orderBy(bs, terms)
+		}
+		return bs.PlaceholderFormat(dialect.Placeholder), nil
 	}
 
-	// add offset
-	if filter.Offset != nil && *(filter.Offset) != 0 {
-		bs = bs.Offset(*(filter.Limit))
+	// build includes
+	if len(query.Include) > 0 {
+		bs, err = ctx.addJoins(bs, tableAlias, query.Include)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bs = bs.Where(wheres)
+	if seek != nil {
+		bs = bs.Where(seek)
+	}
+	if len(terms) > 0 {
+		bs = ctx.orderBy(bs, terms)
 	}
-	return bs, nil
+	bs = dialect.paginate(bs, limit, offset, len(terms) > 0)
+	return bs.PlaceholderFormat(dialect.Placeholder), nil
 }
 
 func (b *Builder) isOperator(op string) bool {
@@ -166,12 +385,27 @@ func (b *Builder) isOperator(op string) bool {
 	return false
 }
 
-func wrapOp(op Op, m map[string]interface{}) ([]sq.Sqlizer, error) {
+// wrapOp build one condition per column of m for a comparison operator.
+//
+// nil operands (including nil pointers and driver.Valuer returning nil) are
+// treated as SQL NULL:
+//   - `$eq: nil` renders `IS NULL`, `$notEq: nil` renders `IS NOT NULL`
+//   - `$gt`, `$gte`, `$lt`, `$lte` and the like operators reject nil, since
+//     comparing with NULL never matches
+//   - nil elements of `$in` match NULL rows (`col IN (...) OR col IS NULL`),
+//     nil elements of `$notIn` exclude NULL rows
+//   - `$notEq` and `$notIn` with non-nil operands do not match NULL rows
+//     unless BuilderConfig.NullSafeNotEq is set
+func (b *builderContext) wrapOp(op Op, m []entry) ([]sq.Sqlizer, error) {
 	switch op {
 	case OpEq:
 		{
 			conds := []sq.Sqlizer{}
-			for k, v := range m {
+			for _, e := range m {
+				k, v := e.key, e.value
+				if isNull(v) {
+					v = nil
+				}
 				cond := sq.Eq{k: v}
 				conds = append(conds, cond)
 			}
@@ -180,8 +414,15 @@ func wrapOp(op Op, m map[string]interface{}) ([]sq.Sqlizer, error) {
 	case OpNotEq:
 		{
 			conds := []sq.Sqlizer{}
-			for k, v := range m {
-				cond := sq.NotEq{k: v}
+			for _, e := range m {
+				k, v := e.key, e.value
+				if isNull(v) {
+					v = nil
+				}
+				var cond sq.Sqlizer = sq.NotEq{k: v}
+				if b.builder.config.NullSafeNotEq && v != nil {
+					cond = sq.Or{cond, sq.Eq{k: nil}}
+				}
 				conds = append(conds, cond)
 			}
 			return conds, nil
@@ -189,7 +430,11 @@ func wrapOp(op Op, m map[string]interface{}) ([]sq.Sqlizer, error) {
 	case OpGt:
 		{
 			conds := []sq.Sqlizer{}
-			for k, v := range m {
+			for _, e := range m {
+				k, v := e.key, e.value
+				if isNull(v) {
+					return nil, errors.New("invalid operand, cannot compare with null")
+				}
 				cond := sq.Gt{k: v}
 				conds = append(conds, cond)
 			}
@@ -198,7 +443,11 @@ func wrapOp(op Op, m map[string]interface{}) ([]sq.Sqlizer, error) {
 	case OpGte:
 		{
 			conds := []sq.Sqlizer{}
-			for k, v := range m {
+			for _, e := range m {
+				k, v := e.key, e.value
+				if isNull(v) {
+					return nil, errors.New("invalid operand, cannot compare with null")
+				}
 				cond := sq.GtOrEq{k: v}
 				conds = append(conds, cond)
 			}
@@ -207,7 +456,11 @@ func wrapOp(op Op, m map[string]interface{}) ([]sq.Sqlizer, error) {
 	case OpLt:
 		{
 			conds := []sq.Sqlizer{}
-			for k, v := range m {
+			for _, e := range m {
+				k, v := e.key, e.value
+				if isNull(v) {
+					return nil, errors.New("invalid operand, cannot compare with null")
+				}
 				cond := sq.Lt{k: v}
 				conds = append(conds, cond)
 			}
@@ -216,15 +469,134 @@ func wrapOp(op Op, m map[string]interface{}) ([]sq.Sqlizer, error) {
 	case OpLte:
 		{
 			conds := []sq.Sqlizer{}
-			for k, v := range m {
+			for _, e := range m {
+				k, v := e.key, e.value
+				if isNull(v) {
+					return nil, errors.New("invalid operand, cannot compare with null")
+				}
 				cond := sq.LtOrEq{k: v}
 				conds = append(conds, cond)
 			}
 			return conds, nil
 		}
+	case OpIn:
+		{
+			conds := []sq.Sqlizer{}
+			for _, e := range m {
+				k, v := e.key, e.value
+				if !isList(v) {
+					return nil, errors.New("invalid operand, $in expects array or slice")
+				}
+				var cond sq.Sqlizer = sq.Eq{k: v}
+				if values, hasNull := splitNulls(v); hasNull {
+					cond = sq.Eq{k: values}
+					if len(values) == 0 {
+						cond = sq.Eq{k: nil}
+					} else {
+						cond = sq.Or{cond, sq.Eq{k: nil}}
+					}
+				}
+				conds = append(conds, cond)
+			}
+			return conds, nil
+		}
+	case OpNotIn:
+		{
+			conds := []sq.Sqlizer{}
+			for _, e := range m {
+				k, v := e.key, e.value
+				if !isList(v) {
+					return nil, errors.New("invalid operand, $notIn expects array or slice")
+				}
+				var cond sq.Sqlizer = sq.NotEq{k: v}
+				if values, hasNull := splitNulls(v); hasNull {
+					cond = sq.NotEq{k: values}
+					if len(values) == 0 {
+						cond = sq.NotEq{k: nil}
+					} else {
+						cond = sq.And{cond, sq.NotEq{k: nil}}
+					}
+				} else if b.builder.config.NullSafeNotEq {
+					cond = sq.Or{cond, sq.Eq{k: nil}}
+				}
+				conds = append(conds, cond)
+			}
+			return conds, nil
+		}
+	case OpIs:
+		{
+			return isConds(m, false, b.builder.dialect())
+		}
+	case OpIsNot:
+		{
+			return isConds(m, true, b.builder.dialect())
+		}
+	case OpIsNull:
+		{
+			return isNullConds(m, false)
+		}
+	case OpNotNull:
+		{
+			return isNullConds(m, true)
+		}
+	case OpBetween:
+		{
+			return rangeConds(m, betweenExpr{})
+		}
+	case OpNotBetween:
+		{
+			return rangeConds(m, betweenExpr{not: true})
+		}
+	case OpRange:
+		{
+			return rangeConds(m, betweenExpr{halfOpen: true})
+		}
+	case OpLike:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{})
+		}
+	case OpILike:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{insensitive: true})
+		}
+	case OpNotLike:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{not: true})
+		}
+	case OpNotILike:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{not: true, insensitive: true})
+		}
+	case OpStartsWith:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{anyAfter: true})
+		}
+	case OpEndsWith:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{anyBefore: true})
+		}
+	case OpContains:
+		{
+			return likeConds(m, b.builder.dialect(), likeExpr{anyBefore: true, anyAfter: true})
+		}
 	default:
 		{
 			return nil, errors.New("invalid op")
 		}
 	}
 }
+
+// isList reports whether v is an array or slice operand. []byte is a single
+// value to database drivers, so it is not treated as a list.
+func isList(v interface{}) bool {
+	if _, ok := v.([]byte); ok {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+		return true
+	default:
+		return false
+	}
+}
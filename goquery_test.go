@@ -1,8 +1,10 @@
 package goquery
 
 import (
+	"database/sql"
 	"reflect"
 	"testing"
+	"time"
 )
 
 // func TestBuilder_parseWhere(t *testing.T) {
@@ -311,11 +313,11 @@ import (
 // 		t.Run(tt.name, func(t *testing.T) {
 // 			got, err := wrapOp(tt.args.op, tt.args.m)
 // 			if (err != nil) != tt.wantErr {
-// 				t.Errorf("wrapOp() error = %v, wantErr %v", err, tt.wantErr)
+// 				t.Errorf("builderContext.wrapOp() error = %v, wantErr %v", err, tt.wantErr)
 // 				return
 // 			}
 // 			if !reflect.DeepEqual(got, tt.want) {
-// 				t.Errorf("wrapOp() = %v, want %v", got, tt.want)
+// 				t.Errorf("builderContext.wrapOp() = %v, want %v", got, tt.want)
 // 			}
 // 		})
 // 	}
@@ -445,6 +447,7 @@ func TestBuilder_Build(t *testing.T) {
 							Table:      "table2",
 							SourceKey:  "id",
 							ForeignKey: "t1id",
+							Attributes: []interface{}{"x"},
 							Where: map[string]interface{}{
 								"x": 4,
 							},
@@ -452,7 +455,67 @@ func TestBuilder_Build(t *testing.T) {
 					},
 				},
 			},
-			want: "",
+			want: `SELECT table1.*, table2.x AS "table2.x" FROM table1 LEFT JOIN table2 ON table1.id = table2.t1id AND ((table2.x = ?)) WHERE ((table1.b = ? AND table1.c = ?) AND (table1.a = ?))`,
+		},
+		{
+			name: "plain nil pointer valuer",
+			b:    builder,
+			args: args{
+				filter: Filter{
+					From:  "table1",
+					Where: map[string]interface{}{"a": (*sql.NullInt64)(nil)},
+				},
+			},
+			want: "SELECT * FROM table1 WHERE ((table1.a IS NULL))",
+		},
+		{
+			name: "ordered map keeps insertion order",
+			b:    builder,
+			args: args{
+				filter: Filter{
+					From: "table1",
+					Where: map[string]interface{}{
+						"$or": []interface{}{
+							NewOrderedMap().Set("z", 1).Set("a", map[string]interface{}{"$lt": 2, "$gt": 0}),
+						},
+					},
+				},
+			},
+			want: "SELECT * FROM table1 WHERE (((((table1.z = ?) AND (table1.a > ? AND table1.a < ?)))))",
+		},
+		{
+			name: "$or",
+			b:    builder,
+			args: args{
+				filter: Filter{
+					From: "table1",
+					Where: map[string]interface{}{
+						"$or": []interface{}{
+							map[string]interface{}{"a": 1},
+							map[string]interface{}{"b": 2},
+						},
+					},
+				},
+			},
+			want: "SELECT * FROM table1 WHERE (((((table1.a = ?)) OR ((table1.b = ?)))))",
+		},
+		{
+			name: "$not $or",
+			b:    builder,
+			args: args{
+				filter: Filter{
+					From: "table1",
+					Where: map[string]interface{}{
+						"$not": map[string]interface{}{
+							"$or": []interface{}{
+								map[string]interface{}{"a": 1},
+								map[string]interface{}{"b": 2},
+							},
+						},
+					},
+				},
+			},
+			want: "SELECT * FROM table1 WHERE (((NOT ((((((table1.a = ?)) OR ((table1.b = ?)))))))))",
 		},
 	}
 	for _, tt := range tests {
@@ -473,3 +536,434 @@ func TestBuilder_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilderContext_wrapOp(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	nullSafe, _ := New(BuilderConfig{NullSafeNotEq: true})
+	mysql, _ := New(BuilderConfig{Dialect: MySQL})
+	sqlServer, _ := New(BuilderConfig{Dialect: SQLServer})
+	type args struct {
+		op Op
+		m  map[string]interface{}
+	}
+	var nilPtr *int
+	var nilValuer *sql.NullInt64
+	tests := []struct {
+		name     string
+		b        *Builder
+		args     args
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "$like",
+			args:     args{op: OpLike, m: map[string]interface{}{"a": "foo%"}},
+			wantSQL:  "a LIKE ?",
+			wantArgs: []interface{}{"foo%"},
+		},
+		{
+			name:     "$notILike",
+			args:     args{op: OpNotILike, m: map[string]interface{}{"a": "foo%"}},
+			wantSQL:  "LOWER(a) NOT LIKE LOWER(?)",
+			wantArgs: []interface{}{"foo%"},
+		},
+		{
+			name:     "$startsWith escapes wildcards",
+			args:     args{op: OpStartsWith, m: map[string]interface{}{"a": `50%_off\`}},
+			wantSQL:  `a LIKE ? ESCAPE '\'`,
+			wantArgs: []interface{}{`50\%\_off\\%`},
+		},
+		{
+			name:     "$endsWith",
+			args:     args{op: OpEndsWith, m: map[string]interface{}{"a": "bar"}},
+			wantSQL:  `a LIKE ? ESCAPE '\'`,
+			wantArgs: []interface{}{"%bar"},
+		},
+		{
+			name:     "$contains",
+			args:     args{op: OpContains, m: map[string]interface{}{"a": "bar"}},
+			wantSQL:  `a LIKE ? ESCAPE '\'`,
+			wantArgs: []interface{}{"%bar%"},
+		},
+		{
+			name:     "$contains mysql escape",
+			b:        mysql,
+			args:     args{op: OpContains, m: map[string]interface{}{"a": "bar"}},
+			wantSQL:  `a LIKE ? ESCAPE '\\'`,
+			wantArgs: []interface{}{"%bar%"},
+		},
+		{
+			name:    "$like with non string operand",
+			args:    args{op: OpLike, m: map[string]interface{}{"a": 1}},
+			wantErr: true,
+		},
+		{
+			name:     "$in with empty list",
+			args:     args{op: OpIn, m: map[string]interface{}{"a": []int{}}},
+			wantSQL:  "(1=0)",
+			wantArgs: []interface{}{},
+		},
+		{
+			name:     "$notIn with empty list",
+			args:     args{op: OpNotIn, m: map[string]interface{}{"a": []int{}}},
+			wantSQL:  "(1=1)",
+			wantArgs: []interface{}{},
+		},
+		{
+			name:     "$eq null",
+			args:     args{op: OpEq, m: map[string]interface{}{"a": nil}},
+			wantSQL:  "a IS NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$notEq nil pointer",
+			args:     args{op: OpNotEq, m: map[string]interface{}{"a": nilPtr}},
+			wantSQL:  "a IS NOT NULL",
+			wantArgs: nil,
+		},
+		{
+			name:    "$gt null",
+			args:    args{op: OpGt, m: map[string]interface{}{"a": nil}},
+			wantErr: true,
+		},
+		{
+			name:    "$lte null",
+			args:    args{op: OpLte, m: map[string]interface{}{"a": nilPtr}},
+			wantErr: true,
+		},
+		{
+			name:     "$in with null element",
+			args:     args{op: OpIn, m: map[string]interface{}{"a": []interface{}{1, nil}}},
+			wantSQL:  "(a IN (?) OR a IS NULL)",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "$in only null",
+			args:     args{op: OpIn, m: map[string]interface{}{"a": []interface{}{nil}}},
+			wantSQL:  "a IS NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$notIn with null element",
+			args:     args{op: OpNotIn, m: map[string]interface{}{"a": []interface{}{1, nil}}},
+			wantSQL:  "(a NOT IN (?) AND a IS NOT NULL)",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "$is null",
+			args:     args{op: OpIs, m: map[string]interface{}{"a": nil}},
+			wantSQL:  "a IS NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$isNot true",
+			args:     args{op: OpIsNot, m: map[string]interface{}{"a": true}},
+			wantSQL:  "a IS NOT TRUE",
+			wantArgs: nil,
+		},
+		{
+			name:     "$is true numeric bool",
+			b:        sqlServer,
+			args:     args{op: OpIs, m: map[string]interface{}{"a": true}},
+			wantSQL:  "a = 1",
+			wantArgs: nil,
+		},
+		{
+			name:     "$isNot false numeric bool",
+			b:        sqlServer,
+			args:     args{op: OpIsNot, m: map[string]interface{}{"a": false}},
+			wantSQL:  "(a <> 0 OR a IS NULL)",
+			wantArgs: nil,
+		},
+		{
+			name:     "$in with nil pointer valuer",
+			args:     args{op: OpIn, m: map[string]interface{}{"a": []interface{}{1, nilValuer}}},
+			wantSQL:  "(a IN (?) OR a IS NULL)",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "$eq nil pointer valuer",
+			args:     args{op: OpEq, m: map[string]interface{}{"a": nilValuer}},
+			wantSQL:  "a IS NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$notEq nil pointer valuer",
+			b:        nullSafe,
+			args:     args{op: OpNotEq, m: map[string]interface{}{"a": nilValuer}},
+			wantSQL:  "a IS NOT NULL",
+			wantArgs: nil,
+		},
+		{
+			name:    "$gt nil pointer valuer",
+			args:    args{op: OpGt, m: map[string]interface{}{"a": nilValuer}},
+			wantErr: true,
+		},
+		{
+			name:    "$is with value",
+			args:    args{op: OpIs, m: map[string]interface{}{"a": 1}},
+			wantErr: true,
+		},
+		{
+			name:     "$isNull true",
+			args:     args{op: OpIsNull, m: map[string]interface{}{"a": true}},
+			wantSQL:  "a IS NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$isNull false",
+			args:     args{op: OpIsNull, m: map[string]interface{}{"a": false}},
+			wantSQL:  "a IS NOT NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$notNull true",
+			args:     args{op: OpNotNull, m: map[string]interface{}{"a": true}},
+			wantSQL:  "a IS NOT NULL",
+			wantArgs: nil,
+		},
+		{
+			name:     "$notEq",
+			args:     args{op: OpNotEq, m: map[string]interface{}{"a": 1}},
+			wantSQL:  "a <> ?",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "null safe $notEq",
+			b:        nullSafe,
+			args:     args{op: OpNotEq, m: map[string]interface{}{"a": 1}},
+			wantSQL:  "(a <> ? OR a IS NULL)",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "$between",
+			args:     args{op: OpBetween, m: map[string]interface{}{"a": []int{1, 5}}},
+			wantSQL:  "a BETWEEN ? AND ?",
+			wantArgs: []interface{}{1, 5},
+		},
+		{
+			name:     "$notBetween",
+			args:     args{op: OpNotBetween, m: map[string]interface{}{"a": [2]string{"a", "m"}}},
+			wantSQL:  "a NOT BETWEEN ? AND ?",
+			wantArgs: []interface{}{"a", "m"},
+		},
+		{
+			name: "$range",
+			args: args{op: OpRange, m: map[string]interface{}{"a": []interface{}{
+				time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+			}}},
+			wantSQL: "(a >= ? AND a < ?)",
+			wantArgs: []interface{}{
+				time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "$between with one element",
+			args:    args{op: OpBetween, m: map[string]interface{}{"a": []int{1}}},
+			wantErr: true,
+		},
+		{
+			name:    "$between with null bound",
+			args:    args{op: OpBetween, m: map[string]interface{}{"a": []interface{}{1, nil}}},
+			wantErr: true,
+		},
+		{
+			name:    "$between with mixed bounds",
+			args:    args{op: OpBetween, m: map[string]interface{}{"a": []interface{}{1, "b"}}},
+			wantErr: true,
+		},
+		{
+			name:     "null safe $notIn",
+			b:        nullSafe,
+			args:     args{op: OpNotIn, m: map[string]interface{}{"a": []int{1, 2}}},
+			wantSQL:  "(a NOT IN (?,?) OR a IS NULL)",
+			wantArgs: []interface{}{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.b
+			if b == nil {
+				b = builder
+			}
+			ctx := &builderContext{builder: b}
+			m, _, _ := mapEntries(tt.args.m)
+			conds, err := ctx.wrapOp(tt.args.op, m)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("builderContext.wrapOp() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(conds) != 1 {
+				t.Errorf("builderContext.wrapOp() = %v, want a single condition", conds)
+				return
+			}
+			sql, params, err := conds[0].ToSql()
+			if err != nil {
+				t.Errorf("builderContext.wrapOp() ToSql error = %v", err)
+				return
+			}
+			if sql != tt.wantSQL || !reflect.DeepEqual(params, tt.wantArgs) {
+				t.Errorf("builderContext.wrapOp() = %v %v, want %v %v", sql, params, tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_deterministic(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	filter := Filter{
+		From: "table1",
+		Where: map[string]interface{}{
+			"e": 5, "d": 4, "c": 3, "b": 2, "a": 1,
+			"$gt": map[string]interface{}{"y": 1, "x": 2},
+		},
+	}
+	want := "SELECT * FROM table1 WHERE ((table1.x > ? AND table1.y > ?) AND (table1.a = ?) AND (table1.b = ?) AND (table1.c = ?) AND (table1.d = ?) AND (table1.e = ?))"
+	wantArgs := []interface{}{2, 1, 1, 2, 3, 4, 5}
+	for i := 0; i < 20; i++ {
+		im, err := builder.Build(filter)
+		if err != nil {
+			t.Fatalf("Builder.Build() error = %v", err)
+		}
+		got, args, err := im.ToSql()
+		if err != nil {
+			t.Fatalf("Builder.Build() err = %v", err)
+		}
+		if got != want || !reflect.DeepEqual(args, wantArgs) {
+			t.Fatalf("Builder.Build() = %v %v, want %v %v", got, args, want, wantArgs)
+		}
+	}
+}
+
+func TestBuilder_Build_dialect(t *testing.T) {
+	var limit uint64 = 10
+	filter := Filter{
+		From:       "user",
+		Attributes: []interface{}{"na]me"},
+		Where: map[string]interface{}{
+			"name": map[string]interface{}{"$iLike": "a%"},
+		},
+		Limit: &limit,
+	}
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		want    string
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			want:    `SELECT "user"."na]me" AS "na]me" FROM "user" WHERE (("user"."name" ILIKE $1)) LIMIT 10`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			want:    "SELECT `user`.`na]me` AS `na]me` FROM `user` WHERE ((LOWER(`user`.`name`) LIKE LOWER(?))) LIMIT 10",
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			want:    `SELECT "user"."na]me" AS "na]me" FROM "user" WHERE ((LOWER("user"."name") LIKE LOWER(?))) LIMIT 10`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			want:    `SELECT [user].[na]]me] AS [na]]me] FROM [user] WHERE ((LOWER([user].[name]) LIKE LOWER(@p1))) ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			want:    `SELECT "user"."na]me" AS "na]me" FROM "user" WHERE ((LOWER("user"."name") LIKE LOWER(:1))) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := New(BuilderConfig{Dialect: tt.dialect})
+			im, err := b.Build(filter)
+			if err != nil {
+				t.Errorf("Builder.Build() error = %v", err)
+				return
+			}
+			got, _, err := im.ToSql()
+			if err != nil {
+				t.Errorf("Builder.Build() = %v, err = %v, want %v", got, err, tt.want)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialect_QuoteIdent(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		ident   string
+		want    string
+	}{
+		{name: "postgres", dialect: Postgres, ident: `a"b`, want: `"a""b"`},
+		{name: "mysql", dialect: MySQL, ident: "a`b", want: "`a``b`"},
+		{name: "sqlserver", dialect: SQLServer, ident: "a]b", want: "[a]]b]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.QuoteIdent(tt.ident); got != tt.want {
+				t.Errorf("Dialect.QuoteIdent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_pagination(t *testing.T) {
+	u := func(v uint64) *uint64 { return &v }
+	plain, _ := New(BuilderConfig{})
+	policy, _ := New(BuilderConfig{Pagination: PaginationPolicy{DefaultLimit: 10, MaxLimit: 100, MaxOffset: 1000}})
+	clamp, _ := New(BuilderConfig{Pagination: PaginationPolicy{MaxLimit: 100, ClampLimit: true}})
+	sqlServer, _ := New(BuilderConfig{Dialect: SQLServer})
+	tests := []struct {
+		name    string
+		b       *Builder
+		limit   *uint64
+		offset  *uint64
+		want    string
+		wantErr *PaginationError
+	}{
+		{name: "offset without limit", b: plain, offset: u(40), want: "SELECT * FROM t WHERE (1=1) OFFSET 40"},
+		{name: "limit and offset", b: plain, limit: u(20), offset: u(40), want: "SELECT * FROM t WHERE (1=1) LIMIT 20 OFFSET 40"},
+		{name: "zero limit", b: plain, limit: u(0), offset: u(0), want: "SELECT * FROM t WHERE (1=1) LIMIT 0"},
+		{name: "zero limit offset fetch", b: sqlServer, limit: u(0), offset: u(20), want: `SELECT * FROM [t] WHERE (1=1) AND (1=0) ORDER BY (SELECT NULL) OFFSET 20 ROWS`},
+		{name: "unbounded", b: plain, want: "SELECT * FROM t WHERE (1=1)"},
+		{name: "default limit", b: policy, want: "SELECT * FROM t WHERE (1=1) LIMIT 10"},
+		{name: "max limit", b: policy, limit: u(101), wantErr: &PaginationError{Field: "limit", Value: 101, Max: 100}},
+		{name: "max offset", b: policy, offset: u(1001), wantErr: &PaginationError{Field: "offset", Value: 1001, Max: 1000}},
+		{name: "clamped limit", b: clamp, limit: u(500), want: "SELECT * FROM t WHERE (1=1) LIMIT 100"},
+		{name: "max limit without default", b: clamp, want: "SELECT * FROM t WHERE (1=1) LIMIT 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := tt.b.Build(Filter{From: "t", Limit: tt.limit, Offset: tt.offset})
+			if tt.wantErr != nil {
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("Builder.Build() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Builder.Build() error = %v", err)
+				return
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
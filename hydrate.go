@@ -0,0 +1,367 @@
+package goquery
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// hydrateNode columns of the main table or of a joined include in the flat
+// result rows
+type hydrateNode struct {
+	// name key of the include in its parent rows
+	name string
+	// many the include is a list
+	many    bool
+	columns []string
+	fields  []string
+	// keys identifying columns, all columns when empty
+	keys []string
+	// dedupe repeated rows are merged, they are only repeated by a primary
+	// key or by the joins of to-many includes below
+	dedupe   bool
+	children []*hydrateNode
+}
+
+// hydrateEntity a de-duplicated row of a node
+type hydrateEntity struct {
+	values   map[string]interface{}
+	children []*hydrateSet
+}
+
+// hydrateSet rows of a node in order of appearance
+type hydrateSet struct {
+	list  []*hydrateEntity
+	byKey map[string]*hydrateEntity
+}
+
+// Hydrate read the rows of a query built from filter and nest the columns
+// of the joined includes under their names: a list of rows for hasMany,
+// belongsToMany and through includes, a row or nil otherwise. Parent and
+// included rows are de-duplicated by primary key when it is selected, by
+// all of their columns when they have to-many includes, and kept as they
+// are otherwise. Included rows whose columns are all NULL did not match.
+// Separate includes are left to BuildPlan. Hydrate closes rows
+func (b *Builder) Hydrate(rows *sql.Rows, filter Filter) ([]map[string]interface{}, error) {
+	defer rows.Close()
+	query, err := b.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+	flat, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	return b.nest(query.Include, flat), nil
+}
+
+// HydrateStructs like Hydrate, and populate dest, a pointer to a slice of
+// structs or struct pointers. Columns and includes are matched with the
+// `db` tag of the fields, or the lowercased field name without tag
+func (b *Builder) HydrateStructs(rows *sql.Rows, filter Filter, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("hydrate: dest must be a pointer to a slice, got %T", dest)
+	}
+	list, err := b.Hydrate(rows, filter)
+	if err != nil {
+		return err
+	}
+	return assignValue(rv.Elem(), list, "")
+}
+
+// scanRows read rows into maps keyed by result column name
+func scanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, column := range columns {
+		if seen[column] {
+			return nil, fmt.Errorf("hydrate: duplicate result column %s", column)
+		}
+		seen[column] = true
+	}
+	list := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := map[string]interface{}{}
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+// nest group flat rows by the joined includes among includes
+func (b *Builder) nest(includes []*IncludeQuery, rows []map[string]interface{}) []map[string]interface{} {
+	root := &hydrateNode{children: hydrateNodes(includes)}
+	paths := map[string]*hydrateNode{"": root}
+	var index func(node *hydrateNode, path string)
+	index = func(node *hydrateNode, path string) {
+		for _, child := range node.children {
+			childPath := child.name
+			if path != "" {
+				childPath = path + "." + child.name
+			}
+			paths[childPath] = child
+			index(child, childPath)
+		}
+	}
+	index(root, "")
+
+	// assign the result columns to their include by path
+	columns := map[string]bool{}
+	for _, row := range rows {
+		for column := range row {
+			columns[column] = true
+		}
+	}
+	sorted := []string{}
+	for column := range columns {
+		sorted = append(sorted, column)
+	}
+	sort.Strings(sorted)
+	for _, column := range sorted {
+		node, field := root, column
+		if i := strings.LastIndex(column, "."); i >= 0 {
+			if n, ok := paths[column[:i]]; ok {
+				node, field = n, column[i+1:]
+			}
+		}
+		node.columns = append(node.columns, column)
+		node.fields = append(node.fields, field)
+		if field == b.primaryKey() {
+			node.keys = []string{column}
+		}
+	}
+	if len(root.keys) > 0 && columns[parentKeyColumn] {
+		// rows of a separate include are repeated per parent
+		root.keys = append(root.keys, parentKeyColumn)
+	}
+	root.setDedupe()
+
+	set := newHydrateSet()
+	for _, row := range rows {
+		set.add(root, row)
+	}
+	return set.maps(root)
+}
+
+func hydrateNodes(includes []*IncludeQuery) []*hydrateNode {
+	nodes := []*hydrateNode{}
+	for _, iq := range includes {
+		if iq.Include.Separate {
+			continue
+		}
+		nodes = append(nodes, &hydrateNode{
+			name:     iq.Include.alias(),
+			many:     iq.toMany(),
+			children: hydrateNodes(iq.Children),
+		})
+	}
+	return nodes
+}
+
+// setDedupe set dedupe of node and its children, reporting whether node
+// has to-many includes
+func (node *hydrateNode) setDedupe() bool {
+	many := false
+	for _, child := range node.children {
+		if child.setDedupe() || child.many {
+			many = true
+		}
+	}
+	node.dedupe = len(node.keys) > 0 || many
+	return many
+}
+
+func newHydrateSet() *hydrateSet {
+	return &hydrateSet{byKey: map[string]*hydrateEntity{}}
+}
+
+// add add the part of row that belongs to node, unless node is an include
+// and the part is all NULL
+func (s *hydrateSet) add(node *hydrateNode, row map[string]interface{}) {
+	matched := false
+	for _, column := range node.columns {
+		if row[column] != nil {
+			matched = true
+		}
+	}
+	if !matched && node.name != "" {
+		return
+	}
+	var entity *hydrateEntity
+	key := ""
+	if node.dedupe {
+		keys := node.keys
+		if len(keys) == 0 {
+			keys = node.columns
+		}
+		identity := []string{}
+		for _, column := range keys {
+			identity = append(identity, fmt.Sprintf("%q", keyString(row[column])))
+		}
+		key = strings.Join(identity, ",")
+		entity = s.byKey[key]
+	}
+	if entity == nil {
+		entity = &hydrateEntity{values: map[string]interface{}{}}
+		for i, column := range node.columns {
+			entity.values[node.fields[i]] = row[column]
+		}
+		for range node.children {
+			entity.children = append(entity.children, newHydrateSet())
+		}
+		if node.dedupe {
+			s.byKey[key] = entity
+		}
+		s.list = append(s.list, entity)
+	}
+	for i, child := range node.children {
+		entity.children[i].add(child, row)
+	}
+}
+
+func (s *hydrateSet) maps(node *hydrateNode) []map[string]interface{} {
+	list := []map[string]interface{}{}
+	for _, entity := range s.list {
+		row := map[string]interface{}{}
+		for field, v := range entity.values {
+			row[field] = v
+		}
+		for i, child := range node.children {
+			children := entity.children[i].maps(child)
+			if child.many {
+				row[child.name] = children
+			} else if len(children) > 0 {
+				row[child.name] = children[0]
+			} else {
+				row[child.name] = nil
+			}
+		}
+		list = append(list, row)
+	}
+	return list
+}
+
+// assignValue set v from a hydrated value, name is the column for errors
+func assignValue(v reflect.Value, value interface{}, name string) error {
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.CanAddr() {
+		if scanner, ok := v.Addr().Interface().(sql.Scanner); ok {
+			return scanner.Scan(value)
+		}
+	}
+	if reflect.TypeOf(value).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := assignValue(elem.Elem(), value, name); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	switch t := value.(type) {
+	case map[string]interface{}:
+		{
+			if v.Kind() != reflect.Struct {
+				return fmt.Errorf("hydrate %s: cannot assign a row to %s", name, v.Type())
+			}
+			return assignStruct(v, t, name)
+		}
+	case []map[string]interface{}:
+		{
+			if v.Kind() != reflect.Slice {
+				return fmt.Errorf("hydrate %s: cannot assign a list to %s", name, v.Type())
+			}
+			list := reflect.MakeSlice(v.Type(), len(t), len(t))
+			for i, row := range t {
+				if err := assignValue(list.Index(i), row, name); err != nil {
+					return err
+				}
+			}
+			v.Set(list)
+			return nil
+		}
+	}
+	rv := reflect.ValueOf(value)
+	if b, ok := value.([]byte); ok && v.Kind() == reflect.String {
+		rv = reflect.ValueOf(string(b))
+	}
+	switch {
+	case v.Kind() == reflect.String && rv.Kind() == reflect.String:
+		{
+			v.SetString(rv.String())
+		}
+	case v.Kind() == reflect.String && rv.Kind() != reflect.String:
+		{
+			// Convert would turn integers into runes
+			return fmt.Errorf("hydrate %s: cannot assign %T to %s", name, value, v.Type())
+		}
+	case rv.Type().ConvertibleTo(v.Type()):
+		{
+			v.Set(rv.Convert(v.Type()))
+		}
+	default:
+		{
+			return fmt.Errorf("hydrate %s: cannot assign %T to %s", name, value, v.Type())
+		}
+	}
+	return nil
+}
+
+// assignStruct set the fields of v from row by `db` tag
+func assignStruct(v reflect.Value, row map[string]interface{}, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			if err := assignStruct(v.Field(i), row, path); err != nil {
+				return err
+			}
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		value, ok := row[name]
+		if !ok {
+			continue
+		}
+		column := name
+		if path != "" {
+			column = path + "." + name
+		}
+		if err := assignValue(v.Field(i), value, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package goquery
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// stubDriver driver answering the queries of a connection with the results
+// registered for its data source name, in order
+type stubDriver struct{}
+
+type stubResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// stubLog results left and queries run on a stub database
+type stubLog struct {
+	results []*stubResult
+	queries []string
+	args    [][]driver.Value
+}
+
+var (
+	stubMu   sync.Mutex
+	stubLogs = map[string]*stubLog{}
+)
+
+func init() {
+	sql.Register("goquery_stub", stubDriver{})
+}
+
+// stubDB open a database whose queries return results
+func stubDB(t *testing.T, results ...*stubResult) (*sql.DB, *stubLog) {
+	stubMu.Lock()
+	dsn := t.Name()
+	log := &stubLog{results: results}
+	stubLogs[dsn] = log
+	stubMu.Unlock()
+	db, err := sql.Open("goquery_stub", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, log
+}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	log, ok := stubLogs[name]
+	if !ok {
+		return nil, errors.New("no stub results")
+	}
+	return &stubConn{log: log}, nil
+}
+
+type stubConn struct {
+	log *stubLog
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{log: c.log, query: query}, nil
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type stubStmt struct {
+	log   *stubLog
+	query string
+}
+
+func (s *stubStmt) Close() error { return nil }
+
+func (s *stubStmt) NumInput() int { return -1 }
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	log := s.log
+	if len(log.results) == 0 {
+		return nil, errors.New("no stub results left")
+	}
+	result := log.results[0]
+	log.results = log.results[1:]
+	log.queries = append(log.queries, s.query)
+	log.args = append(log.args, args)
+	return &stubRows{result: result}, nil
+}
+
+type stubRows struct {
+	result *stubResult
+	next   int
+}
+
+func (r *stubRows) Columns() []string { return r.result.columns }
+
+func (r *stubRows) Close() error { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+func hydrateBuilder() *Builder {
+	builder, _ := New(BuilderConfig{Models: Models{
+		"authors": Model{
+			Associations: map[string]Association{
+				"posts":   {Kind: HasMany, Table: "posts", ForeignKey: "author_id"},
+				"profile": {Kind: HasOne, Table: "profiles", ForeignKey: "author_id"},
+			},
+		},
+		"posts": Model{
+			Associations: map[string]Association{
+				"tags": {
+					Kind:    BelongsToMany,
+					Table:   "tags",
+					Through: &IncludeThrough{TableName: "post_tags", SourceKey: "post_id", ForeignKey: "tag_id"},
+				},
+			},
+		},
+	}})
+	return builder
+}
+
+var hydrateFilter = Filter{
+	From: "authors",
+	Include: []Include{
+		{Association: "profile", Attributes: []interface{}{"bio"}},
+		{Association: "posts", Attributes: []interface{}{"id", "title"}, Include: []Include{
+			{Association: "tags", Attributes: []interface{}{"id", "label"}},
+		}},
+	},
+}
+
+var hydrateResult = &stubResult{
+	columns: []string{"id", "name", "profile.bio", "posts.id", "posts.title", "posts.tags.id", "posts.tags.label"},
+	rows: [][]driver.Value{
+		{int64(1), []byte("ann"), "hi", int64(10), "first", int64(100), "go"},
+		{int64(1), []byte("ann"), "hi", int64(10), "first", int64(101), "sql"},
+		{int64(1), []byte("ann"), "hi", int64(11), "second", nil, nil},
+		{int64(2), []byte("bob"), nil, nil, nil, nil, nil},
+	},
+}
+
+func TestBuilder_Hydrate(t *testing.T) {
+	db, _ := stubDB(t, hydrateResult)
+	defer db.Close()
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := hydrateBuilder().Hydrate(rows, hydrateFilter)
+	if err != nil {
+		t.Fatalf("Builder.Hydrate() error = %v", err)
+	}
+	want := []map[string]interface{}{
+		{
+			"id":      int64(1),
+			"name":    []byte("ann"),
+			"profile": map[string]interface{}{"bio": "hi"},
+			"posts": []map[string]interface{}{
+				{"id": int64(10), "title": "first", "tags": []map[string]interface{}{
+					{"id": int64(100), "label": "go"},
+					{"id": int64(101), "label": "sql"},
+				}},
+				{"id": int64(11), "title": "second", "tags": []map[string]interface{}{}},
+			},
+		},
+		{
+			"id":      int64(2),
+			"name":    []byte("bob"),
+			"profile": nil,
+			"posts":   []map[string]interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Builder.Hydrate() = %v, want %v", got, want)
+	}
+}
+
+func TestBuilder_HydrateStructs(t *testing.T) {
+	type tag struct {
+		ID    int    `db:"id"`
+		Label string `db:"label"`
+	}
+	type post struct {
+		ID    int64
+		Title string
+		Tags  []*tag `db:"tags"`
+	}
+	type profile struct {
+		Bio sql.NullString `db:"bio"`
+	}
+	type author struct {
+		ID      int `db:"id"`
+		Name    string
+		Profile *profile `db:"profile"`
+		Posts   []post   `db:"posts"`
+	}
+	db, _ := stubDB(t, hydrateResult)
+	defer db.Close()
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []author
+	if err := hydrateBuilder().HydrateStructs(rows, hydrateFilter, &got); err != nil {
+		t.Fatalf("Builder.HydrateStructs() error = %v", err)
+	}
+	want := []author{
+		{
+			ID:      1,
+			Name:    "ann",
+			Profile: &profile{Bio: sql.NullString{String: "hi", Valid: true}},
+			Posts: []post{
+				{ID: 10, Title: "first", Tags: []*tag{{ID: 100, Label: "go"}, {ID: 101, Label: "sql"}}},
+				{ID: 11, Title: "second", Tags: []*tag{}},
+			},
+		},
+		{ID: 2, Name: "bob", Posts: []post{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Builder.HydrateStructs() = %+v, want %+v", got, want)
+	}
+
+	if err := hydrateBuilder().HydrateStructs(rows, hydrateFilter, got); err == nil {
+		t.Errorf("Builder.HydrateStructs() non-pointer dest, want error")
+	}
+}
+
+func TestBuilder_Hydrate_duplicateColumns(t *testing.T) {
+	db, _ := stubDB(t, &stubResult{
+		columns: []string{"id", "name", "id"},
+		rows:    [][]driver.Value{{int64(1), "ann", int64(10)}},
+	})
+	defer db.Close()
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hydrateBuilder().Hydrate(rows, Filter{From: "authors"}); err == nil {
+		t.Errorf("Builder.Hydrate() error = nil, want duplicate column error")
+	}
+}
+
+func TestBuilder_Hydrate_duplicateRows(t *testing.T) {
+	db, _ := stubDB(t, &stubResult{
+		columns: []string{"status"},
+		rows:    [][]driver.Value{{"open"}, {"open"}, {"closed"}},
+	})
+	defer db.Close()
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := hydrateBuilder().Hydrate(rows, Filter{From: "posts", Attributes: []interface{}{"status"}})
+	if err != nil {
+		t.Fatalf("Builder.Hydrate() error = %v", err)
+	}
+	want := []map[string]interface{}{{"status": "open"}, {"status": "open"}, {"status": "closed"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Builder.Hydrate() = %v, want %v", got, want)
+	}
+}
@@ -6,39 +6,180 @@ import (
 	sq "github.com/Masterminds/squirrel"
 )
 
-func (b *builderContext) addJoins(bs sq.SelectBuilder, tableName string, includes []Include) (sq.SelectBuilder, error) {
-	for _, include := range includes {
-		if len(include.Where) == 0 {
-			if include.Through == nil {
-				src := b.toFullName(tableName, include.SourceKey)
-				dst := b.toFullName(include.Table, include.ForeignKey)
-				clause := fmt.Sprintf("%s ON %s = %s", include.Table, src, dst)
-				bs = bs.LeftJoin(clause)
-			} else {
-				thSrc := b.toFullName(tableName, include.SourceKey)
-				thDst := b.toFullName(include.Through.TableName, include.Through.SourceKey)
-				throughClause := fmt.Sprintf("%s ON %s = %s", include.Through.TableName, thSrc, thDst)
-
-				src := b.toFullName(include.Through.TableName, include.Through.ForeignKey)
-				dst := b.toFullName(include.Table, include.ForeignKey)
-				clause := fmt.Sprintf("%s ON %s = %s", include.Table, src, dst)
-				bs = bs.LeftJoin(throughClause).LeftJoin(clause)
-			}
-		} else {
-			if include.Through == nil {
-				src := b.toFullName(tableName, include.SourceKey)
-				dst := b.toFullName(include.Table, include.ForeignKey)
-				clause := fmt.Sprintf("LEFT INNER JOIN %s ON %s = %s", include.Table, src, dst)
-				bs = bs.JoinClause(clause)
-				nb := b.inherit()
-				nb.tableName = include.Table
-				where, err := nb.parseWhere(include.Where)
+// addJoins join the includes. Conditions of optional includes are added to
+// the ON clause so they only filter the included rows, conditions of
+// required includes are added to WHERE. Optional includes with a joint table
+// and conditions join the joint table and the target table as one nested
+// join, so that joint rows without matching target rows are dropped too
+func (b *builderContext) addJoins(bs sq.SelectBuilder, tableName string, includes []*IncludeQuery) (sq.SelectBuilder, error) {
+	for _, iq := range joinedIncludes(includes) {
+		include := iq.Include
+		alias := iq.Alias
+		parent := tableName
+		if iq.Parent != "" {
+			parent = iq.Parent
+		}
+		join := bs.LeftJoin
+		if include.Required {
+			join = bs.Join
+		}
+		if include.SourceKey == "" || include.ForeignKey == "" {
+			return bs, fmt.Errorf("include %s: missing source or foreign key", iq.Path)
+		}
+		table, err := b.joinTable(include.Table, alias)
+		if err != nil {
+			return bs, err
+		}
+		if include.Through == nil {
+			src := b.toFullName(parent, include.SourceKey)
+			dst := b.toFullName(alias, include.ForeignKey)
+			clause := fmt.Sprintf("%s ON %s = %s", table, src, dst)
+			if include.Required {
+				bs = join(clause)
+				continue
+			}
+			on, args, err := b.onClause(clause, iq.Where)
+			if err != nil {
+				return bs, err
+			}
+			bs = join(on, args...)
+			continue
+		}
+
+		if include.Through.SourceKey == "" || include.Through.ForeignKey == "" {
+			return bs, fmt.Errorf("include %s: missing through source or foreign key", iq.Path)
+		}
+		throughTable, err := b.joinTable(include.Through.TableName, iq.Through)
+		if err != nil {
+			return bs, err
+		}
+		thSrc := b.toFullName(parent, include.SourceKey)
+		thDst := b.toFullName(iq.Through, include.Through.SourceKey)
+		throughClause := fmt.Sprintf("%s ON %s = %s", throughTable, thSrc, thDst)
+
+		src := b.toFullName(iq.Through, include.Through.ForeignKey)
+		dst := b.toFullName(alias, include.ForeignKey)
+		clause := fmt.Sprintf("%s ON %s = %s", table, src, dst)
+		switch {
+		case include.Required:
+			{
+				bs = bs.Join(throughClause).Join(clause)
+			}
+		case iq.Where == nil:
+			{
+				on, args, err := b.onClause(throughClause, iq.ThroughWhere)
 				if err != nil {
 					return bs, err
 				}
-				bs = bs.Where(where)
+				bs = bs.LeftJoin(on, args...).LeftJoin(clause)
+			}
+		default:
+			{
+				inner, innerArgs, err := b.onClause(clause, iq.Where)
+				if err != nil {
+					return bs, err
+				}
+				nested := fmt.Sprintf("(%s JOIN %s) ON %s = %s", throughTable, inner, thSrc, thDst)
+				on, args, err := b.onClause(nested, iq.ThroughWhere)
+				if err != nil {
+					return bs, err
+				}
+				bs = bs.LeftJoin(on, append(innerArgs, args...)...)
 			}
 		}
 	}
+	// conditions of required includes
+	for _, iq := range joinedIncludes(includes) {
+		if !iq.Include.Required {
+			continue
+		}
+		for _, node := range []Node{iq.ThroughWhere, iq.Where} {
+			if node == nil {
+				continue
+			}
+			where, err := b.render(node)
+			if err != nil {
+				return bs, err
+			}
+			bs = bs.Where(where)
+		}
+	}
 	return bs, nil
 }
+
+// onClause append a condition to the ON clause of a join
+func (b *builderContext) onClause(clause string, node Node) (string, []interface{}, error) {
+	if node == nil {
+		return clause, nil, nil
+	}
+	cond, err := b.render(node)
+	if err != nil {
+		return "", nil, err
+	}
+	sql, args, err := cond.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s AND %s", clause, sql), args, nil
+}
+
+// joinTable quoted table of a join, aliased when the alias differs from the
+// table name
+func (b *builderContext) joinTable(table string, alias string) (string, error) {
+	quoted, err := b.quoteTable(table)
+	if err != nil {
+		return "", err
+	}
+	if alias != table {
+		return b.builder.dialect().aliasTable(quoted, b.quote(alias)), nil
+	}
+	return quoted, nil
+}
+
+// exists EXISTS condition matching the rows of tableName that have a row
+// of a required include, and of its required includes in turn
+func (b *builderContext) exists(tableName string, iq *IncludeQuery) (sq.Sqlizer, error) {
+	include := iq.Include
+	table, err := b.joinTable(include.Table, iq.Alias)
+	if err != nil {
+		return nil, err
+	}
+	sub := sq.Select("1")
+	if th := include.Through; th != nil {
+		throughTable, err := b.joinTable(th.TableName, iq.Through)
+		if err != nil {
+			return nil, err
+		}
+		src := b.toFullName(iq.Through, th.ForeignKey)
+		dst := b.toFullName(iq.Alias, include.ForeignKey)
+		sub = sub.From(throughTable).Join(fmt.Sprintf("%s ON %s = %s", table, src, dst)).
+			Where(fmt.Sprintf("%s = %s", b.toFullName(iq.Through, th.SourceKey), b.toFullName(tableName, include.SourceKey)))
+	} else {
+		sub = sub.From(table).
+			Where(fmt.Sprintf("%s = %s", b.toFullName(iq.Alias, include.ForeignKey), b.toFullName(tableName, include.SourceKey)))
+	}
+	for _, node := range []Node{iq.ThroughWhere, iq.Where} {
+		if node == nil {
+			continue
+		}
+		cond, err := b.render(node)
+		if err != nil {
+			return nil, err
+		}
+		sub = sub.Where(cond)
+	}
+	for _, child := range iq.Children {
+		if child.Include.Required && !child.Include.Separate {
+			cond, err := b.exists(iq.Alias, child)
+			if err != nil {
+				return nil, err
+			}
+			sub = sub.Where(cond)
+		}
+	}
+	sql, args, err := sub.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return sq.Expr(fmt.Sprintf("EXISTS (%s)", sql), args...), nil
+}
@@ -0,0 +1,417 @@
+package goquery
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_Build_includeWhere(t *testing.T) {
+	models := Models{
+		"posts": Model{
+			Associations: map[string]Association{
+				"tags": {
+					Kind:    BelongsToMany,
+					Table:   "tags",
+					Through: &IncludeThrough{TableName: "post_tags", SourceKey: "post_id", ForeignKey: "tag_id"},
+				},
+			},
+		},
+	}
+	builder, _ := New(BuilderConfig{Models: models, Dialect: Postgres})
+	tests := []struct {
+		name     string
+		include  Include
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "optional",
+			include:  Include{Table: "comments", Attributes: []interface{}{"id"}, SourceKey: "id", ForeignKey: "post_id", Where: map[string]interface{}{"approved": true}},
+			wantSQL:  `SELECT "posts".*, "comments"."id" AS "comments.id" FROM "posts" LEFT JOIN "comments" ON "posts"."id" = "comments"."post_id" AND (("comments"."approved" = $1)) WHERE (("posts"."draft" = $2))`,
+			wantArgs: []interface{}{true, false},
+		},
+		{
+			name:     "required",
+			include:  Include{Table: "comments", Attributes: []interface{}{"id"}, SourceKey: "id", ForeignKey: "post_id", Required: true, Where: map[string]interface{}{"approved": true}},
+			wantSQL:  `SELECT "posts".*, "comments"."id" AS "comments.id" FROM "posts" JOIN "comments" ON "posts"."id" = "comments"."post_id" WHERE (("comments"."approved" = $1)) AND (("posts"."draft" = $2))`,
+			wantArgs: []interface{}{true, false},
+		},
+		{
+			name:    "optional through",
+			include: Include{Association: "tags", Attributes: []interface{}{"id"}, Where: map[string]interface{}{"label": "go"}, Through: &IncludeThrough{Where: map[string]interface{}{"primary": true}}},
+			wantSQL: `SELECT "posts".*, "tags"."id" AS "tags.id" FROM "posts" LEFT JOIN ("post_tags" AS "tags__post_tags" JOIN "tags" ON "tags__post_tags"."tag_id" = "tags"."id" AND (("tags"."label" = $1))) ` +
+				`ON "posts"."id" = "tags__post_tags"."post_id" AND (("tags__post_tags"."primary" = $2)) WHERE (("posts"."draft" = $3))`,
+			wantArgs: []interface{}{"go", true, false},
+		},
+		{
+			name:     "optional through with joint table conditions only",
+			include:  Include{Association: "tags", Attributes: []interface{}{"id"}, Through: &IncludeThrough{Where: map[string]interface{}{"primary": true}}},
+			wantSQL:  `SELECT "posts".*, "tags"."id" AS "tags.id" FROM "posts" LEFT JOIN "post_tags" AS "tags__post_tags" ON "posts"."id" = "tags__post_tags"."post_id" AND (("tags__post_tags"."primary" = $1)) LEFT JOIN "tags" ON "tags__post_tags"."tag_id" = "tags"."id" WHERE (("posts"."draft" = $2))`,
+			wantArgs: []interface{}{true, false},
+		},
+		{
+			name:    "required through",
+			include: Include{Association: "tags", Attributes: []interface{}{"id"}, Required: true, Where: map[string]interface{}{"label": "go"}, Through: &IncludeThrough{Where: map[string]interface{}{"primary": true}}},
+			wantSQL: `SELECT "posts".*, "tags"."id" AS "tags.id" FROM "posts" JOIN "post_tags" AS "tags__post_tags" ON "posts"."id" = "tags__post_tags"."post_id" JOIN "tags" ON "tags__post_tags"."tag_id" = "tags"."id" ` +
+				`WHERE (("tags__post_tags"."primary" = $1)) AND (("tags"."label" = $2)) AND (("posts"."draft" = $3))`,
+			wantArgs: []interface{}{true, "go", false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.Build(Filter{
+				From:    "posts",
+				Where:   map[string]interface{}{"draft": false},
+				Include: []Include{tt.include},
+			})
+			if err != nil {
+				t.Fatalf("Builder.Build() error = %v", err)
+			}
+			sql, args, _ := im.ToSql()
+			if sql != tt.wantSQL {
+				t.Errorf("Builder.Build() sql = %v, want %v", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Builder.Build() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_quotedTables(t *testing.T) {
+	builder, _ := New(BuilderConfig{Dialect: Postgres})
+	unquoted, _ := New(BuilderConfig{})
+	tests := []struct {
+		name    string
+		builder *Builder
+		filter  Filter
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "reserved word and mixed case",
+			filter: Filter{
+				From:    "Orders",
+				Include: []Include{{Table: "user", SourceKey: "userId", ForeignKey: "id", Attributes: []interface{}{"id"}}},
+			},
+			want: `SELECT "Orders".*, "user"."id" AS "user.id" FROM "Orders" LEFT JOIN "user" ON "Orders"."userId" = "user"."id" WHERE (1=1)`,
+		},
+		{
+			name: "injection",
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Table: `users" ON 1=1 --`, SourceKey: "id", ForeignKey: "id", Attributes: []interface{}{"id"}}},
+			},
+			want: `SELECT "posts".*, "users"" ON 1=1 --"."id" AS "users"" ON 1=1 --.id" FROM "posts" LEFT JOIN "users"" ON 1=1 --" ON "posts"."id" = "users"" ON 1=1 --"."id" WHERE (1=1)`,
+		},
+		{
+			name: "schema-qualified",
+			filter: Filter{
+				From: "analytics.events",
+				Include: []Include{{
+					Table:      "analytics.sessions",
+					SourceKey:  "session_id",
+					ForeignKey: "id",
+					Attributes: []interface{}{"id"},
+				}},
+				Order: []interface{}{"sessions.started_at"},
+			},
+			want: `SELECT "events".*, "sessions"."id" AS "sessions.id" FROM "analytics"."events" LEFT JOIN "analytics"."sessions" AS "sessions" ON "events"."session_id" = "sessions"."id" WHERE (1=1) ORDER BY "sessions"."started_at" ASC`,
+		},
+		{
+			name:    "unquoted injection",
+			builder: unquoted,
+			filter:  Filter{From: "posts", Include: []Include{{Table: "users ON 1=1 --", SourceKey: "id", ForeignKey: "id", Attributes: []interface{}{"id"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "unquoted alias injection",
+			builder: unquoted,
+			filter:  Filter{From: "posts", Include: []Include{{Table: "users", As: "p ON 1=1 --", SourceKey: "id", ForeignKey: "id", Attributes: []interface{}{"id"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "unquoted key injection",
+			builder: unquoted,
+			filter:  Filter{From: "posts", Include: []Include{{Table: "users", SourceKey: "id OR 1=1", ForeignKey: "id", Attributes: []interface{}{"id"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "unquoted foreign key injection",
+			builder: unquoted,
+			filter:  Filter{From: "posts", Include: []Include{{Table: "users", SourceKey: "id", ForeignKey: "id --", Attributes: []interface{}{"id"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "unquoted through key injection",
+			builder: unquoted,
+			filter: Filter{From: "posts", Include: []Include{{
+				Table: "tags", SourceKey: "id", ForeignKey: "id", Attributes: []interface{}{"id"},
+				Through: &IncludeThrough{TableName: "post_tags", SourceKey: "post_id", ForeignKey: "tag_id) --"},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:    "unquoted attribute injection",
+			builder: unquoted,
+			filter:  Filter{From: "posts", Include: []Include{{Table: "users", SourceKey: "id", ForeignKey: "id", Attributes: []interface{}{"name, password"}}}},
+			wantErr: true,
+		},
+		{
+			name:   "quoted alias",
+			filter: Filter{From: "posts", Include: []Include{{Table: "users", As: `p" ON 1=1 --`, SourceKey: "author_id", ForeignKey: "id", Attributes: []interface{}{"id"}}}},
+			want:   `SELECT "posts".*, "p"" ON 1=1 --"."id" AS "p"" ON 1=1 --.id" FROM "posts" LEFT JOIN "users" AS "p"" ON 1=1 --" ON "posts"."author_id" = "p"" ON 1=1 --"."id" WHERE (1=1)`,
+		},
+		{
+			name:    "empty name part",
+			filter:  Filter{From: "posts", Include: []Include{{Table: "analytics.", SourceKey: "id", ForeignKey: "id", Attributes: []interface{}{"id"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "missing key",
+			filter:  Filter{From: "posts", Include: []Include{{Table: "users", ForeignKey: "id", Attributes: []interface{}{"id"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := builder
+			if tt.builder != nil {
+				b = tt.builder
+			}
+			im, err := b.Build(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_includeWithoutAttributes(t *testing.T) {
+	include := Include{Table: "posts", SourceKey: "id", ForeignKey: "author_id"}
+	builder, _ := New(BuilderConfig{})
+	if _, err := builder.Build(Filter{From: "authors", Include: []Include{include}}); err == nil {
+		t.Errorf("Builder.Build() error = nil, want an error for an include without attributes")
+	}
+	include.Separate = true
+	if _, err := builder.Build(Filter{From: "authors", Include: []Include{include}}); err != nil {
+		t.Errorf("Builder.Build() separate include error = %v", err)
+	}
+	builder, _ = New(BuilderConfig{Schema: Schema{
+		"authors": Table{"id": {Type: TypeNumber}},
+		"posts":   Table{"author_id": {Type: TypeNumber}},
+	}})
+	include.Separate = false
+	im, err := builder.Build(Filter{From: "authors", Include: []Include{include}})
+	if err != nil {
+		t.Fatalf("Builder.Build() error = %v", err)
+	}
+	want := `SELECT authors.id AS id, posts.author_id AS "posts.author_id" FROM authors LEFT JOIN posts ON authors.id = posts.author_id WHERE (1=1)`
+	if got, _, _ := im.ToSql(); got != want {
+		t.Errorf("Builder.Build() = %v, want %v", got, want)
+	}
+}
+
+func TestBuilder_Build_subQuery(t *testing.T) {
+	models := Models{
+		"authors": Model{
+			Associations: map[string]Association{
+				"posts":   {Kind: HasMany, Table: "posts", ForeignKey: "author_id"},
+				"profile": {Kind: HasOne, Table: "profiles", ForeignKey: "author_id"},
+			},
+		},
+		"posts": Model{
+			Associations: map[string]Association{
+				"comments": {Kind: HasMany, Table: "comments", ForeignKey: "post_id"},
+			},
+		},
+	}
+	builder, _ := New(BuilderConfig{Models: models, Dialect: Postgres})
+	limit := uint64(20)
+	tests := []struct {
+		name     string
+		filter   Filter
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name: "hasMany with limit",
+			filter: Filter{
+				From:    "authors",
+				Where:   map[string]interface{}{"active": true},
+				Order:   []interface{}{"name"},
+				Include: []Include{{Association: "posts", Attributes: []interface{}{"title"}}},
+				Limit:   &limit,
+			},
+			wantSQL: `SELECT "authors".*, "posts"."title" AS "posts.title" FROM (SELECT "authors".* FROM "authors" WHERE (("authors"."active" = $1)) ORDER BY "authors"."name" ASC LIMIT 20) AS "authors" ` +
+				`LEFT JOIN "posts" ON "authors"."id" = "posts"."author_id" ORDER BY "authors"."name" ASC`,
+			wantArgs: []interface{}{true},
+		},
+		{
+			name: "required hasMany",
+			filter: Filter{
+				From:    "authors",
+				Include: []Include{{Association: "posts", Required: true, Attributes: []interface{}{"title"}, Where: map[string]interface{}{"draft": false}}},
+				Limit:   &limit,
+			},
+			wantSQL: `SELECT "authors".*, "posts"."title" AS "posts.title" FROM (SELECT "authors".* FROM "authors" WHERE (1=1) AND EXISTS (SELECT 1 FROM "posts" WHERE "posts"."author_id" = "authors"."id" AND (("posts"."draft" = $1))) LIMIT 20) AS "authors" ` +
+				`JOIN "posts" ON "authors"."id" = "posts"."author_id" WHERE (("posts"."draft" = $2))`,
+			wantArgs: []interface{}{false, false},
+		},
+		{
+			name: "required below optional hasMany",
+			filter: Filter{
+				From: "authors",
+				Include: []Include{{Association: "posts", Attributes: []interface{}{"title"}, Include: []Include{
+					{Association: "comments", Required: true, Attributes: []interface{}{"body"}, Where: map[string]interface{}{"approved": true}},
+				}}},
+				Limit: &limit,
+			},
+			wantSQL: `SELECT "authors".*, "posts"."title" AS "posts.title", "posts__comments"."body" AS "posts.comments.body" FROM (SELECT "authors".* FROM "authors" WHERE (1=1) AND ` +
+				`EXISTS (SELECT 1 FROM "posts" WHERE "posts"."author_id" = "authors"."id" AND EXISTS (SELECT 1 FROM "comments" AS "posts__comments" WHERE "posts__comments"."post_id" = "posts"."id" AND (("posts__comments"."approved" = $1)))) LIMIT 20) AS "authors" ` +
+				`JOIN "posts" ON "authors"."id" = "posts"."author_id" JOIN "comments" AS "posts__comments" ON "posts"."id" = "posts__comments"."post_id" WHERE (("posts__comments"."approved" = $2))`,
+			wantArgs: []interface{}{true, true},
+		},
+		{
+			name: "hasOne with limit",
+			filter: Filter{
+				From:    "authors",
+				Include: []Include{{Association: "profile", Attributes: []interface{}{"bio"}}},
+				Limit:   &limit,
+			},
+			wantSQL: `SELECT "authors".*, "profile"."bio" AS "profile.bio" FROM "authors" LEFT JOIN "profiles" AS "profile" ON "authors"."id" = "profile"."author_id" WHERE (1=1) LIMIT 20`,
+		},
+		{
+			name: "forced",
+			filter: Filter{
+				From:     "authors",
+				Include:  []Include{{Association: "profile", Attributes: []interface{}{"bio"}}},
+				SubQuery: true,
+			},
+			wantSQL: `SELECT "authors".*, "profile"."bio" AS "profile.bio" FROM (SELECT "authors".* FROM "authors" WHERE (1=1)) AS "authors" LEFT JOIN "profiles" AS "profile" ON "authors"."id" = "profile"."author_id"`,
+		},
+		{
+			name: "forced with order",
+			filter: Filter{
+				From:     "authors",
+				Include:  []Include{{Association: "profile", Attributes: []interface{}{"bio"}}},
+				Order:    []interface{}{"name"},
+				SubQuery: true,
+			},
+			wantSQL: `SELECT "authors".*, "profile"."bio" AS "profile.bio" FROM (SELECT "authors".* FROM "authors" WHERE (1=1)) AS "authors" ` +
+				`LEFT JOIN "profiles" AS "profile" ON "authors"."id" = "profile"."author_id" ORDER BY "authors"."name" ASC`,
+		},
+		{
+			name: "order by include",
+			filter: Filter{
+				From:    "authors",
+				Include: []Include{{Association: "posts", Attributes: []interface{}{"title"}}},
+				Order:   []interface{}{"posts.title"},
+				Limit:   &limit,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.Build(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			sql, args, _ := im.ToSql()
+			if sql != tt.wantSQL {
+				t.Errorf("Builder.Build() sql = %v, want %v", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Builder.Build() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_bareTableAlias(t *testing.T) {
+	builder, _ := New(BuilderConfig{Dialect: Oracle, Models: Models{
+		"authors": Model{
+			Associations: map[string]Association{
+				"posts":   {Kind: HasMany, Table: "posts", ForeignKey: "author_id"},
+				"profile": {Kind: HasOne, Table: "profiles", ForeignKey: "author_id"},
+			},
+		},
+	}})
+	limit := uint64(20)
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantSQL string
+	}{
+		{
+			name:    "aliased include",
+			filter:  Filter{From: "authors", Attributes: []interface{}{"id"}, Include: []Include{{Association: "profile", Attributes: []interface{}{"bio"}}}},
+			wantSQL: `SELECT "authors"."id" AS "id", "profile"."bio" AS "profile.bio" FROM "authors" LEFT JOIN "profiles" "profile" ON "authors"."id" = "profile"."author_id" WHERE (1=1)`,
+		},
+		{
+			name:   "subquery",
+			filter: Filter{From: "authors", Attributes: []interface{}{"id"}, Include: []Include{{Association: "posts", Attributes: []interface{}{"title"}}}, Order: []interface{}{"id"}, Limit: &limit},
+			wantSQL: `SELECT "authors"."id" AS "id", "posts"."title" AS "posts.title" FROM (SELECT "authors".* FROM "authors" WHERE (1=1) ORDER BY "authors"."id" ASC OFFSET 0 ROWS FETCH NEXT 20 ROWS ONLY) "authors" ` +
+				`LEFT JOIN "posts" ON "authors"."id" = "posts"."author_id" ORDER BY "authors"."id" ASC`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.Build(tt.filter)
+			if err != nil {
+				t.Fatalf("Builder.Build() error = %v", err)
+			}
+			sql, _, _ := im.ToSql()
+			if sql != tt.wantSQL {
+				t.Errorf("Builder.Build() sql = %v, want %v", sql, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestBuilder_ParseQuery_includeInjection(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	include := "include[0][table]=users&include[0][sourceKey]=author_id&include[0][foreignKey]=id&include[0][attributes]=id"
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "valid", query: include + "&include[0][as]=p&include[0][where][active]=true"},
+		{name: "alias", query: include + "&include[0][as]=p ON 1=1 --", wantErr: true},
+		{name: "where column", query: include + "&include[0][where][x %3D 1) OR (1]=1", wantErr: true},
+		{
+			name:    "through where column",
+			query:   include + "&include[0][through][tableName]=user_posts&include[0][through][sourceKey]=post_id&include[0][through][foreignKey]=user_id&include[0][through][where][x %3D 1) OR (1]=1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			filter, err := builder.ParseQuery(values)
+			if err != nil {
+				t.Fatalf("Builder.ParseQuery() error = %v", err)
+			}
+			filter.From = "posts"
+			if _, err := builder.Build(filter); (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
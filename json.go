@@ -0,0 +1,452 @@
+package goquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// NumberMode how JSON numbers in conditions are decoded
+type NumberMode int
+
+const (
+	// NumberAuto int64 for integers, uint64 for integers above the int64
+	// range and float64 for everything else
+	NumberAuto NumberMode = iota
+	// NumberJSON keep numbers as json.Number
+	NumberJSON
+	// NumberFloat64 float64, like encoding/json
+	NumberFloat64
+)
+
+// JSONOptions options of JSON filter decoding
+type JSONOptions struct {
+	Numbers NumberMode
+}
+
+// JSONError error of JSON filter decoding, Path locates the offending
+// element, e.g. `$.where.age["$in"][1]`
+type JSONError struct {
+	Path string
+	Err  error
+}
+
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// ParseJSON decode a filter from JSON, see JSONOptions.Parse
+func ParseJSON(data []byte) (Filter, error) {
+	return JSONOptions{}.Parse(data)
+}
+
+// UnmarshalJSON implement json.Unmarshaler with ParseJSON
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	filter, err := ParseJSON(data)
+	if err != nil {
+		return err
+	}
+	*f = filter
+	return nil
+}
+
+// Parse decode a filter from JSON. Unknown keys of the filter, its includes
+// and their through tables are rejected, numbers are decoded according to
+// o.Numbers, errors are reported as *JSONError
+func (o JSONOptions) Parse(data []byte) (Filter, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return Filter{}, &JSONError{Path: "$", Err: err}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return Filter{}, &JSONError{Path: "$", Err: errors.New("unexpected data after filter")}
+	}
+	d := jsonDecoder{o}
+	return d.filter(v, "$")
+}
+
+type jsonDecoder struct {
+	opts JSONOptions
+}
+
+func (d jsonDecoder) filter(v interface{}, path string) (Filter, error) {
+	filter := Filter{}
+	obj, err := jsonObject(v, path, "from", "where", "attributes", "include", "order", "offset", "limit", "keyset", "after", "before", "subQuery")
+	if err != nil {
+		return filter, err
+	}
+	if v, ok := obj["from"]; ok {
+		if filter.From, err = jsonString(v, jsonKey(path, "from")); err != nil {
+			return filter, err
+		}
+	}
+	if v, ok := obj["where"]; ok && v != nil {
+		if filter.Where, err = d.where(v, jsonKey(path, "where")); err != nil {
+			return filter, err
+		}
+	}
+	if v, ok := obj["attributes"]; ok && v != nil {
+		if filter.Attributes, err = jsonAttributes(v, jsonKey(path, "attributes")); err != nil {
+			return filter, err
+		}
+	}
+	if v, ok := obj["include"]; ok && v != nil {
+		p := jsonKey(path, "include")
+		list, err := jsonArray(v, p)
+		if err != nil {
+			return filter, err
+		}
+		for i, elem := range list {
+			include, err := d.include(elem, jsonIndex(p, i))
+			if err != nil {
+				return filter, err
+			}
+			filter.Include = append(filter.Include, include)
+		}
+	}
+	if v, ok := obj["order"]; ok && v != nil {
+		p := jsonKey(path, "order")
+		list, err := jsonArray(v, p)
+		if err != nil {
+			return filter, err
+		}
+		for i, elem := range list {
+			term, err := jsonOrder(elem, jsonIndex(p, i))
+			if err != nil {
+				return filter, err
+			}
+			filter.Order = append(filter.Order, term)
+		}
+	}
+	if v, ok := obj["offset"]; ok && v != nil {
+		if filter.Offset, err = jsonUint(v, jsonKey(path, "offset")); err != nil {
+			return filter, err
+		}
+	}
+	if v, ok := obj["limit"]; ok && v != nil {
+		if filter.Limit, err = jsonUint(v, jsonKey(path, "limit")); err != nil {
+			return filter, err
+		}
+	}
+	if filter.Keyset, err = jsonOptionalBool(obj, path, "keyset"); err != nil {
+		return filter, err
+	}
+	if filter.After, err = jsonOptionalString(obj, path, "after"); err != nil {
+		return filter, err
+	}
+	if filter.Before, err = jsonOptionalString(obj, path, "before"); err != nil {
+		return filter, err
+	}
+	if filter.SubQuery, err = jsonOptionalBool(obj, path, "subQuery"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func (d jsonDecoder) include(v interface{}, path string) (Include, error) {
+	include := Include{}
+	obj, err := jsonObject(v, path, "association", "table", "as", "sourceKey", "foreignKey", "through", "where", "include", "attributes", "required", "separate")
+	if err != nil {
+		return include, err
+	}
+	if include.Association, err = jsonOptionalString(obj, path, "association"); err != nil {
+		return include, err
+	}
+	if include.As, err = jsonOptionalString(obj, path, "as"); err != nil {
+		return include, err
+	}
+	if include.Required, err = jsonOptionalBool(obj, path, "required"); err != nil {
+		return include, err
+	}
+	if include.Separate, err = jsonOptionalBool(obj, path, "separate"); err != nil {
+		return include, err
+	}
+	if v, ok := obj["attributes"]; ok && v != nil {
+		if include.Attributes, err = jsonAttributes(v, jsonKey(path, "attributes")); err != nil {
+			return include, err
+		}
+	}
+	if include.Table, err = jsonOptionalString(obj, path, "table"); err != nil {
+		return include, err
+	}
+	if include.SourceKey, err = jsonOptionalString(obj, path, "sourceKey"); err != nil {
+		return include, err
+	}
+	if include.ForeignKey, err = jsonOptionalString(obj, path, "foreignKey"); err != nil {
+		return include, err
+	}
+	if v, ok := obj["through"]; ok && v != nil {
+		p := jsonKey(path, "through")
+		th, err := jsonObject(v, p, "tableName", "sourceKey", "foreignKey", "where")
+		if err != nil {
+			return include, err
+		}
+		through := &IncludeThrough{}
+		if through.TableName, err = jsonOptionalString(th, p, "tableName"); err != nil {
+			return include, err
+		}
+		if through.SourceKey, err = jsonOptionalString(th, p, "sourceKey"); err != nil {
+			return include, err
+		}
+		if through.ForeignKey, err = jsonOptionalString(th, p, "foreignKey"); err != nil {
+			return include, err
+		}
+		if v, ok := th["where"]; ok && v != nil {
+			if through.Where, err = d.where(v, jsonKey(p, "where")); err != nil {
+				return include, err
+			}
+		}
+		include.Through = through
+	}
+	if v, ok := obj["where"]; ok && v != nil {
+		if include.Where, err = d.where(v, jsonKey(path, "where")); err != nil {
+			return include, err
+		}
+	}
+	if v, ok := obj["include"]; ok && v != nil {
+		p := jsonKey(path, "include")
+		list, err := jsonArray(v, p)
+		if err != nil {
+			return include, err
+		}
+		for i, elem := range list {
+			child, err := d.include(elem, jsonIndex(p, i))
+			if err != nil {
+				return include, err
+			}
+			include.Include = append(include.Include, child)
+		}
+	}
+	return include, nil
+}
+
+func (d jsonDecoder) where(v interface{}, path string) (map[string]interface{}, error) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, &JSONError{Path: path, Err: errors.New("expected object")}
+	}
+	where, err := d.value(v, path)
+	if err != nil {
+		return nil, err
+	}
+	return where.(map[string]interface{}), nil
+}
+
+// value convert the numbers of a decoded condition value
+func (d jsonDecoder) value(v interface{}, path string) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		{
+			m := map[string]interface{}{}
+			entries, _, _ := mapEntries(t)
+			for _, e := range entries {
+				value, err := d.value(e.value, jsonKey(path, e.key))
+				if err != nil {
+					return nil, err
+				}
+				m[e.key] = value
+			}
+			return m, nil
+		}
+	case []interface{}:
+		{
+			list := []interface{}{}
+			for i, elem := range t {
+				value, err := d.value(elem, jsonIndex(path, i))
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			return list, nil
+		}
+	case json.Number:
+		{
+			n, err := d.number(t)
+			if err != nil {
+				return nil, &JSONError{Path: path, Err: err}
+			}
+			return n, nil
+		}
+	default:
+		{
+			return v, nil
+		}
+	}
+}
+
+func (d jsonDecoder) number(n json.Number) (interface{}, error) {
+	switch d.opts.Numbers {
+	case NumberJSON:
+		{
+			return n, nil
+		}
+	case NumberFloat64:
+		{
+			return n.Float64()
+		}
+	default:
+		{
+			if i, err := n.Int64(); err == nil {
+				return i, nil
+			}
+			if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+				return u, nil
+			}
+			f, err := n.Float64()
+			if err != nil {
+				return nil, err
+			}
+			if math.IsInf(f, 0) {
+				return nil, fmt.Errorf("number out of range: %s", n)
+			}
+			return f, nil
+		}
+	}
+}
+
+func jsonObject(v interface{}, path string, known ...string) (map[string]interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, &JSONError{Path: path, Err: errors.New("expected object")}
+	}
+	entries, _, _ := mapEntries(obj)
+	for _, e := range entries {
+		if !containsString(known, e.key) {
+			return nil, &JSONError{Path: jsonKey(path, e.key), Err: errors.New("unknown key")}
+		}
+	}
+	return obj, nil
+}
+
+func jsonAttributes(v interface{}, path string) ([]interface{}, error) {
+	list, err := jsonArray(v, path)
+	if err != nil {
+		return nil, err
+	}
+	attrs := []interface{}{}
+	for i, elem := range list {
+		attr, err := jsonString(elem, jsonIndex(path, i))
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// jsonOrder decode an order term given as string, array of strings or
+// object with the keys of OrderTerm
+func jsonOrder(v interface{}, path string) (OrderTerm, error) {
+	obj, isObject := v.(map[string]interface{})
+	if !isObject {
+		term, err := ParseOrder(v)
+		if err != nil {
+			return term, &JSONError{Path: path, Err: err}
+		}
+		return term, nil
+	}
+	term := OrderTerm{}
+	if _, err := jsonObject(obj, path, "field", "desc", "nulls", "collate"); err != nil {
+		return term, err
+	}
+	var err error
+	if term.Field, err = jsonOptionalString(obj, path, "field"); err != nil {
+		return term, err
+	}
+	if v, ok := obj["desc"]; ok && v != nil {
+		desc, ok := v.(bool)
+		if !ok {
+			return term, &JSONError{Path: jsonKey(path, "desc"), Err: errors.New("expected bool")}
+		}
+		term.Desc = desc
+	}
+	nulls, err := jsonOptionalString(obj, path, "nulls")
+	if err != nil {
+		return term, err
+	}
+	term.Nulls = Nulls(nulls)
+	if term.Collate, err = jsonOptionalString(obj, path, "collate"); err != nil {
+		return term, err
+	}
+	if err := validateOrder(term); err != nil {
+		return term, &JSONError{Path: path, Err: err}
+	}
+	return term, nil
+}
+
+func jsonArray(v interface{}, path string) ([]interface{}, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, &JSONError{Path: path, Err: errors.New("expected array")}
+	}
+	return list, nil
+}
+
+func jsonString(v interface{}, path string) (string, error) {
+	str, ok := v.(string)
+	if !ok {
+		return "", &JSONError{Path: path, Err: errors.New("expected string")}
+	}
+	return str, nil
+}
+
+func jsonOptionalString(obj map[string]interface{}, path string, key string) (string, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return "", nil
+	}
+	return jsonString(v, jsonKey(path, key))
+}
+
+func jsonOptionalBool(obj map[string]interface{}, path string, key string) (bool, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, &JSONError{Path: jsonKey(path, key), Err: errors.New("expected bool")}
+	}
+	return b, nil
+}
+
+func jsonUint(v interface{}, path string) (*uint64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, &JSONError{Path: path, Err: errors.New("expected number")}
+	}
+	u, err := strconv.ParseUint(n.String(), 10, 64)
+	if err != nil {
+		return nil, &JSONError{Path: path, Err: errors.New("expected non-negative integer")}
+	}
+	return &u, nil
+}
+
+var jsonIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func jsonKey(path string, key string) string {
+	if jsonIdent.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+}
+
+func jsonIndex(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package goquery
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	var limit uint64 = 20
+	tests := []struct {
+		name     string
+		data     string
+		want     Filter
+		wantPath string
+	}{
+		{
+			name: "full",
+			data: `{
+				"from": "users",
+				"where": {"id": 9007199254740993, "score": {"$gt": 1.5}, "$or": [{"a": 1}]},
+				"attributes": ["id", "name"],
+				"include": [{"table": "posts", "sourceKey": "id", "foreignKey": "user_id", "where": {"draft": false}}],
+				"order": ["name DESC"],
+				"limit": 20
+			}`,
+			want: Filter{
+				From: "users",
+				Where: map[string]interface{}{
+					"id":    int64(9007199254740993),
+					"score": map[string]interface{}{"$gt": 1.5},
+					"$or":   []interface{}{map[string]interface{}{"a": int64(1)}},
+				},
+				Attributes: []interface{}{"id", "name"},
+				Include: []Include{
+					{
+						Table:      "posts",
+						SourceKey:  "id",
+						ForeignKey: "user_id",
+						Where:      map[string]interface{}{"draft": false},
+					},
+				},
+				Order: []interface{}{OrderTerm{Field: "name", Desc: true}},
+				Limit: &limit,
+			},
+		},
+		{
+			name: "order forms",
+			data: `{"from": "users", "order": ["-id", ["name", "ASC", "NULLS LAST"], {"field": "email", "desc": true, "collate": "nocase"}]}`,
+			want: Filter{
+				From: "users",
+				Order: []interface{}{
+					OrderTerm{Field: "id", Desc: true},
+					OrderTerm{Field: "name", Nulls: NullsLast},
+					OrderTerm{Field: "email", Desc: true, Collate: "nocase"},
+				},
+			},
+		},
+		{
+			name:     "invalid order",
+			data:     `{"from": "users", "order": ["id", {"field": "name", "nulls": "middle"}]}`,
+			wantPath: "$.order[1]",
+		},
+		{
+			name:     "unknown top-level key",
+			data:     `{"from": "users", "wher": {}}`,
+			wantPath: "$.wher",
+		},
+		{
+			name:     "unknown include key",
+			data:     `{"from": "users", "include": [{"table": "posts", "alias": "p"}]}`,
+			wantPath: "$.include[0].alias",
+		},
+		{
+			name:     "attribute type",
+			data:     `{"from": "users", "attributes": ["id", 1]}`,
+			wantPath: "$.attributes[1]",
+		},
+		{
+			name:     "negative limit",
+			data:     `{"from": "users", "limit": -1}`,
+			wantPath: "$.limit",
+		},
+		{
+			name:     "where number out of range",
+			data:     `{"from": "users", "where": {"a": {"$in": [1, 1e400]}}}`,
+			wantPath: `$.where.a["$in"][1]`,
+		},
+		{
+			name:     "syntax error",
+			data:     `{"from": `,
+			wantPath: "$",
+		},
+		{
+			name:     "trailing data",
+			data:     `{"from": "users"} {}`,
+			wantPath: "$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSON([]byte(tt.data))
+			if tt.wantPath != "" {
+				jsonErr, ok := err.(*JSONError)
+				if !ok || jsonErr.Path != tt.wantPath {
+					t.Errorf("ParseJSON() error = %v, want path %v", err, tt.wantPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ParseJSON() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONOptions_Parse(t *testing.T) {
+	data := []byte(`{"from": "t", "where": {"a": 1, "b": 2.5}}`)
+	tests := []struct {
+		name string
+		opts JSONOptions
+		want map[string]interface{}
+	}{
+		{
+			name: "json.Number",
+			opts: JSONOptions{Numbers: NumberJSON},
+			want: map[string]interface{}{"a": json.Number("1"), "b": json.Number("2.5")},
+		},
+		{
+			name: "float64",
+			opts: JSONOptions{Numbers: NumberFloat64},
+			want: map[string]interface{}{"a": 1.0, "b": 2.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.Parse(data)
+			if err != nil {
+				t.Errorf("JSONOptions.Parse() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got.Where, tt.want) {
+				t.Errorf("JSONOptions.Parse() = %v, want %v", got.Where, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter_UnmarshalJSON(t *testing.T) {
+	var req struct {
+		Filter Filter `json:"filter"`
+	}
+	err := json.Unmarshal([]byte(`{"filter": {"from": "t", "where": {"a": 1}}}`), &req)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := map[string]interface{}{"a": int64(1)}
+	if !reflect.DeepEqual(req.Filter.Where, want) {
+		t.Errorf("json.Unmarshal() = %v, want %v", req.Filter.Where, want)
+	}
+	err = json.Unmarshal([]byte(`{"filter": {"from": "t", "bogus": 1}}`), &req)
+	if err == nil {
+		t.Errorf("json.Unmarshal() error = nil, want unknown key error")
+	}
+}
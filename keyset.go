@@ -0,0 +1,165 @@
+package goquery
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// EncodeCursor encode the order values of a row into an opaque cursor
+func EncodeCursor(values ...interface{}) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decode a cursor made by EncodeCursor, numbers are decoded
+// like NumberAuto
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var list []interface{}
+	if err := dec.Decode(&list); err != nil || list == nil {
+		return nil, errors.New("invalid cursor")
+	}
+	v, err := jsonDecoder{}.value(list, "$")
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return v.([]interface{}), nil
+}
+
+// Cursor cursor of row for the keyset order of filter, row is keyed by
+// column name. Use the cursor of the last row of a page as Filter.After to
+// get the next page, the cursor of the first row as Filter.Before to get
+// the previous page
+func (b *Builder) Cursor(filter Filter, row map[string]interface{}) (string, error) {
+	terms, err := b.keysetTerms(filter)
+	if err != nil {
+		return "", err
+	}
+	values := []interface{}{}
+	for _, term := range terms {
+		v, ok := row[term.Field]
+		if !ok {
+			return "", fmt.Errorf("row has no column %s", term.Field)
+		}
+		values = append(values, v)
+	}
+	return EncodeCursor(values...)
+}
+
+func (b *Builder) primaryKey() string {
+	if b.config.PrimaryKey != "" {
+		return b.config.PrimaryKey
+	}
+	return "id"
+}
+
+// keysetTerms order of filter with the primary key appended as tie-breaker
+func (b *Builder) keysetTerms(filter Filter) ([]OrderTerm, error) {
+	terms, err := b.orderTerms(filter)
+	if err != nil {
+		return nil, err
+	}
+	hasKey := false
+	for _, term := range terms {
+		if term.Nulls != NullsDefault || term.Collate != "" {
+			return nil, fmt.Errorf("order by %s: nulls placement and collation are not supported with keyset pagination", term.Field)
+		}
+		if term.Field == b.primaryKey() {
+			hasKey = true
+		}
+	}
+	if !hasKey {
+		terms = append(terms, OrderTerm{Field: b.primaryKey()})
+	}
+	return terms, nil
+}
+
+// keyset order terms of keyset pagination and the seek condition of the
+// cursor, nil without cursor
+func (b *builderContext) keyset(filter Filter) ([]OrderTerm, sq.Sqlizer, error) {
+	if filter.After != "" && filter.Before != "" {
+		return nil, nil, errors.New("after and before cannot be combined")
+	}
+	if filter.Offset != nil && *(filter.Offset) != 0 {
+		return nil, nil, errors.New("offset cannot be combined with keyset pagination")
+	}
+	terms, err := b.builder.keysetTerms(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	cursor := filter.After
+	if filter.Before != "" {
+		// walk backwards from the cursor
+		cursor = filter.Before
+		for i := range terms {
+			terms[i].Desc = !terms[i].Desc
+		}
+	}
+	if cursor == "" {
+		return terms, nil, nil
+	}
+	values, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values) != len(terms) {
+		return nil, nil, errors.New("cursor does not match order")
+	}
+	for _, v := range values {
+		if v == nil {
+			return nil, nil, errors.New("cursor cannot seek past null values")
+		}
+	}
+	return terms, b.seek(terms, values), nil
+}
+
+// seek condition of the rows after values in the order of terms
+func (b *builderContext) seek(terms []OrderTerm, values []interface{}) sq.Sqlizer {
+	columns := []string{}
+	for _, term := range terms {
+		columns = append(columns, b.orderColumn(term))
+	}
+	sameDir := true
+	for _, term := range terms {
+		if term.Desc != terms[0].Desc {
+			sameDir = false
+		}
+	}
+	if sameDir && len(terms) > 1 && b.builder.dialect().RowValues {
+		op := ">"
+		if terms[0].Desc {
+			op = "<"
+		}
+		sql := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, sq.Placeholders(len(values)))
+		return sq.Expr(sql, values...)
+	}
+	// (a > ?) OR (a = ? AND b > ?) OR ...
+	or := sq.Or{}
+	for i, term := range terms {
+		and := sq.And{}
+		for j := 0; j < i; j++ {
+			and = append(and, sq.Eq{columns[j]: values[j]})
+		}
+		if term.Desc {
+			and = append(and, sq.Lt{columns[i]: values[i]})
+		} else {
+			and = append(and, sq.Gt{columns[i]: values[i]})
+		}
+		or = append(or, and)
+	}
+	return or
+}
@@ -0,0 +1,114 @@
+package goquery
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_Build_keyset(t *testing.T) {
+	limit := uint64(10)
+	offset := uint64(20)
+	cursor, _ := EncodeCursor("2020-01-01", 5)
+	tests := []struct {
+		name     string
+		config   BuilderConfig
+		filter   Filter
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:    "first page",
+			filter:  Filter{From: "posts", Keyset: true, Limit: &limit},
+			wantSQL: "SELECT * FROM posts WHERE (1=1) ORDER BY posts.id ASC LIMIT 10",
+		},
+		{
+			name:     "after, mixed directions",
+			filter:   Filter{From: "posts", Order: []interface{}{"created_at DESC"}, After: cursor, Limit: &limit},
+			wantSQL:  "SELECT * FROM posts WHERE (1=1) AND ((posts.created_at < ?) OR (posts.created_at = ? AND posts.id > ?)) ORDER BY posts.created_at DESC, posts.id ASC LIMIT 10",
+			wantArgs: []interface{}{"2020-01-01", "2020-01-01", int64(5)},
+		},
+		{
+			name:     "before reverses order",
+			filter:   Filter{From: "posts", Order: []interface{}{"created_at DESC"}, Before: cursor, Limit: &limit},
+			wantSQL:  "SELECT * FROM posts WHERE (1=1) AND ((posts.created_at > ?) OR (posts.created_at = ? AND posts.id < ?)) ORDER BY posts.created_at ASC, posts.id DESC LIMIT 10",
+			wantArgs: []interface{}{"2020-01-01", "2020-01-01", int64(5)},
+		},
+		{
+			name:     "row values",
+			config:   BuilderConfig{Dialect: Postgres},
+			filter:   Filter{From: "posts", Order: []interface{}{"created_at"}, After: cursor, Limit: &limit},
+			wantSQL:  `SELECT * FROM "posts" WHERE (1=1) AND ("posts"."created_at", "posts"."id") > ($1,$2) ORDER BY "posts"."created_at" ASC, "posts"."id" ASC LIMIT 10`,
+			wantArgs: []interface{}{"2020-01-01", int64(5)},
+		},
+		{
+			name:     "custom primary key",
+			config:   BuilderConfig{PrimaryKey: "uuid"},
+			filter:   Filter{From: "posts", Order: []interface{}{"created_at"}, After: cursor},
+			wantSQL:  "SELECT * FROM posts WHERE (1=1) AND ((posts.created_at > ?) OR (posts.created_at = ? AND posts.uuid > ?)) ORDER BY posts.created_at ASC, posts.uuid ASC",
+			wantArgs: []interface{}{"2020-01-01", "2020-01-01", int64(5)},
+		},
+		{
+			name:    "after and before",
+			filter:  Filter{From: "posts", After: cursor, Before: cursor},
+			wantErr: true,
+		},
+		{
+			name:    "offset",
+			filter:  Filter{From: "posts", Keyset: true, Offset: &offset},
+			wantErr: true,
+		},
+		{
+			name:    "cursor does not match order",
+			filter:  Filter{From: "posts", After: cursor},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cursor",
+			filter:  Filter{From: "posts", After: "!"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, _ := New(tt.config)
+			im, err := builder.Build(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			sql, args, _ := im.ToSql()
+			if sql != tt.wantSQL {
+				t.Errorf("Builder.Build() sql = %v, want %v", sql, tt.wantSQL)
+			}
+			if len(args) > 0 || len(tt.wantArgs) > 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("Builder.Build() args = %v, want %v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestBuilder_Cursor(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	filter := Filter{From: "posts", Order: []interface{}{"created_at DESC"}}
+	cursor, err := builder.Cursor(filter, map[string]interface{}{"id": 5, "created_at": "2020-01-01", "title": "x"})
+	if err != nil {
+		t.Fatalf("Builder.Cursor() error = %v", err)
+	}
+	got, err := DecodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	want := []interface{}{"2020-01-01", int64(5)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeCursor() = %v, want %v", got, want)
+	}
+	if _, err := builder.Cursor(filter, map[string]interface{}{"id": 5}); err == nil {
+		t.Errorf("Builder.Cursor() missing column, want error")
+	}
+}
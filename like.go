@@ -0,0 +1,83 @@
+package goquery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// likeEscape escape character used when wildcards are added by the builder
+const likeEscape = `\`
+
+var likeEscaper = strings.NewReplacer(
+	likeEscape, likeEscape+likeEscape,
+	"%", likeEscape+"%",
+	"_", likeEscape+"_",
+)
+
+// likeExpr a LIKE condition on a single column
+type likeExpr struct {
+	column      string
+	pattern     string
+	not         bool
+	insensitive bool
+	// anyBefore and anyAfter add `%` around the escaped pattern
+	anyBefore bool
+	anyAfter  bool
+	// nativeILike render ILIKE instead of lowering both sides
+	nativeILike bool
+	// escapeLiteral SQL literal of likeEscape
+	escapeLiteral string
+}
+
+func (e likeExpr) ToSql() (string, []interface{}, error) {
+	column := e.column
+	placeholder := "?"
+	op := "LIKE"
+	if e.insensitive {
+		if e.nativeILike {
+			op = "ILIKE"
+		} else {
+			column = fmt.Sprintf("LOWER(%s)", column)
+			placeholder = "LOWER(?)"
+		}
+	}
+	if e.not {
+		op = "NOT " + op
+	}
+	if !e.anyBefore && !e.anyAfter {
+		sql := fmt.Sprintf("%s %s %s", column, op, placeholder)
+		return sql, []interface{}{e.pattern}, nil
+	}
+	pattern := likeEscaper.Replace(e.pattern)
+	if e.anyBefore {
+		pattern = "%" + pattern
+	}
+	if e.anyAfter {
+		pattern = pattern + "%"
+	}
+	sql := fmt.Sprintf("%s %s %s ESCAPE %s", column, op, placeholder, e.escapeLiteral)
+	return sql, []interface{}{pattern}, nil
+}
+
+// likeConds build one likeExpr per column of m, using tmpl for the flags
+func likeConds(m []entry, dialect *Dialect, tmpl likeExpr) ([]sq.Sqlizer, error) {
+	tmpl.nativeILike = dialect.ILike
+	tmpl.escapeLiteral = dialect.likeEscapeLiteral()
+	conds := []sq.Sqlizer{}
+	for _, e := range m {
+		k, v := e.key, e.value
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.String {
+			return nil, errors.New("invalid operand, like operators expect string")
+		}
+		cond := tmpl
+		cond.column = k
+		cond.pattern = rv.String()
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
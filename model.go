@@ -0,0 +1,163 @@
+package goquery
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AssociationKind cardinality of an association
+type AssociationKind int
+
+const (
+	// BelongsTo the source row references one target row,
+	// `source.sourceKey = target.foreignKey`
+	BelongsTo AssociationKind = iota
+	// HasOne one target row references the source row
+	HasOne
+	// HasMany any number of target rows reference the source row
+	HasMany
+	// BelongsToMany source and target rows are linked by a through table
+	BelongsToMany
+)
+
+func (k AssociationKind) String() string {
+	switch k {
+	case BelongsTo:
+		{
+			return "belongsTo"
+		}
+	case HasOne:
+		{
+			return "hasOne"
+		}
+	case HasMany:
+		{
+			return "hasMany"
+		}
+	case BelongsToMany:
+		{
+			return "belongsToMany"
+		}
+	default:
+		{
+			return fmt.Sprintf("AssociationKind(%d)", int(k))
+		}
+	}
+}
+
+// Association join of a model with another table. SourceKey is a column of
+// the source table, ForeignKey a column of the target table, like in
+// Include. Keys left empty default to the primary key, except the foreign
+// key column of the association kind, which is required:
+// SourceKey for BelongsTo and ForeignKey for HasOne and HasMany.
+// BelongsToMany requires Through
+type Association struct {
+	Kind       AssociationKind
+	Table      string
+	SourceKey  string
+	ForeignKey string
+	Through    *IncludeThrough
+}
+
+// Model associations of a table by name
+type Model struct {
+	Associations map[string]Association
+}
+
+// Models models by table name
+type Models map[string]Model
+
+// resolve fill in the default keys of the associations, or fail on an
+// incomplete association
+func (m Models) resolve(primaryKey string) (Models, error) {
+	resolved := Models{}
+	tables := []string{}
+	for table := range m {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		model := Model{Associations: map[string]Association{}}
+		for name, assoc := range m[table].Associations {
+			if assoc.Table == "" {
+				return nil, fmt.Errorf("association %s of %s: missing table", name, table)
+			}
+			switch assoc.Kind {
+			case BelongsTo:
+				{
+					if assoc.SourceKey == "" {
+						return nil, fmt.Errorf("association %s of %s: missing source key", name, table)
+					}
+				}
+			case HasOne, HasMany:
+				{
+					if assoc.ForeignKey == "" {
+						return nil, fmt.Errorf("association %s of %s: missing foreign key", name, table)
+					}
+				}
+			case BelongsToMany:
+				{
+					th := assoc.Through
+					if th == nil || th.TableName == "" || th.SourceKey == "" || th.ForeignKey == "" {
+						return nil, fmt.Errorf("association %s of %s: missing through table or keys", name, table)
+					}
+					through := *th
+					assoc.Through = &through
+				}
+			default:
+				{
+					return nil, fmt.Errorf("association %s of %s: invalid kind %v", name, table, assoc.Kind)
+				}
+			}
+			if assoc.SourceKey == "" {
+				assoc.SourceKey = primaryKey
+			}
+			if assoc.ForeignKey == "" {
+				assoc.ForeignKey = primaryKey
+			}
+			model.Associations[name] = assoc
+		}
+		resolved[table] = model
+	}
+	return resolved, nil
+}
+
+// association look up an association of table
+func (b *Builder) association(table string, name string) (*Association, error) {
+	assoc, ok := b.models[table].Associations[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown association %s of %s", name, table)
+	}
+	return &assoc, nil
+}
+
+// resolveInclude fill in the table and keys of an include given by
+// association. The through table of the association can only be given
+// conditions
+func (b *Builder) resolveInclude(table string, include Include) (Include, *Association, error) {
+	if include.Association == "" {
+		return include, nil, nil
+	}
+	th := include.Through
+	if include.Table != "" || include.SourceKey != "" || include.ForeignKey != "" ||
+		(th != nil && (th.TableName != "" || th.SourceKey != "" || th.ForeignKey != "")) {
+		return include, nil, fmt.Errorf("include %s: association cannot be combined with table, keys or through", include.Association)
+	}
+	assoc, err := b.association(table, include.Association)
+	if err != nil {
+		return include, nil, err
+	}
+	if th != nil && assoc.Through == nil {
+		return include, nil, fmt.Errorf("include %s: association has no through table", include.Association)
+	}
+	include.Table = assoc.Table
+	include.SourceKey = assoc.SourceKey
+	include.ForeignKey = assoc.ForeignKey
+	include.Through = assoc.Through
+	if th != nil {
+		through := *assoc.Through
+		through.Where = th.Where
+		include.Through = &through
+	}
+	return include, assoc, nil
+}
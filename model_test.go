@@ -0,0 +1,346 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestBuilder_Build_association(t *testing.T) {
+	models := Models{
+		"posts": Model{
+			Associations: map[string]Association{
+				"author":   {Kind: BelongsTo, Table: "users", SourceKey: "author_id"},
+				"comments": {Kind: HasMany, Table: "comments", ForeignKey: "post_id"},
+				"tags": {
+					Kind:    BelongsToMany,
+					Table:   "tags",
+					Through: &IncludeThrough{TableName: "post_tags", SourceKey: "post_id", ForeignKey: "tag_id"},
+				},
+			},
+		},
+	}
+	builder, err := New(BuilderConfig{Models: models})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		include []Include
+		order   []interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "belongs to",
+			include: []Include{{Association: "author", Attributes: []interface{}{"id"}}},
+			order:   []interface{}{"author.name"},
+			want:    "SELECT posts.*, author.id AS \"author.id\" FROM posts LEFT JOIN users AS author ON posts.author_id = author.id WHERE (1=1) ORDER BY author.name ASC",
+		},
+		{
+			name:    "has many",
+			include: []Include{{Association: "comments", Attributes: []interface{}{"id"}}},
+			want:    "SELECT posts.*, comments.id AS \"comments.id\" FROM posts LEFT JOIN comments ON posts.id = comments.post_id WHERE (1=1)",
+		},
+		{
+			name:    "belongs to many",
+			include: []Include{{Association: "tags", Attributes: []interface{}{"id"}}},
+			want:    "SELECT posts.*, tags.id AS \"tags.id\" FROM posts LEFT JOIN post_tags AS tags__post_tags ON posts.id = tags__post_tags.post_id LEFT JOIN tags ON tags__post_tags.tag_id = tags.id WHERE (1=1)",
+		},
+		{
+			name:    "unknown association",
+			include: []Include{{Association: "likes"}},
+			wantErr: true,
+		},
+		{
+			name:    "association and table",
+			include: []Include{{Association: "author", Table: "users"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.Build(Filter{From: "posts", Include: tt.include, Order: tt.order})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_schemaQualifiedAssociation(t *testing.T) {
+	builder, _ := New(BuilderConfig{Dialect: Postgres, Models: Models{
+		"analytics.events": Model{
+			Associations: map[string]Association{
+				"user": {Kind: BelongsTo, Table: "users", SourceKey: "user_id"},
+			},
+		},
+	}})
+	im, err := builder.Build(Filter{From: "analytics.events", Include: []Include{{Association: "user", Attributes: []interface{}{"id"}}}})
+	if err != nil {
+		t.Fatalf("Builder.Build() error = %v", err)
+	}
+	want := `SELECT "events".*, "user"."id" AS "user.id" FROM "analytics"."events" LEFT JOIN "users" AS "user" ON "events"."user_id" = "user"."id" WHERE (1=1)`
+	if got, _, _ := im.ToSql(); got != want {
+		t.Errorf("Builder.Build() = %v, want %v", got, want)
+	}
+}
+
+func TestNew_models(t *testing.T) {
+	tests := []struct {
+		name   string
+		models Models
+	}{
+		{
+			name:   "missing table",
+			models: Models{"posts": {Associations: map[string]Association{"author": {SourceKey: "author_id"}}}},
+		},
+		{
+			name:   "belongs to without source key",
+			models: Models{"posts": {Associations: map[string]Association{"author": {Table: "users"}}}},
+		},
+		{
+			name:   "has many without foreign key",
+			models: Models{"users": {Associations: map[string]Association{"posts": {Kind: HasMany, Table: "posts"}}}},
+		},
+		{
+			name:   "belongs to many without through",
+			models: Models{"posts": {Associations: map[string]Association{"tags": {Kind: BelongsToMany, Table: "tags"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(BuilderConfig{Models: tt.models}); err == nil {
+				t.Errorf("New() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_nestedInclude(t *testing.T) {
+	models := Models{
+		"posts": Model{
+			Associations: map[string]Association{
+				"author":   {Kind: BelongsTo, Table: "users", SourceKey: "author_id"},
+				"comments": {Kind: HasMany, Table: "comments", ForeignKey: "post_id"},
+				"tags": {
+					Kind:    BelongsToMany,
+					Table:   "tags",
+					Through: &IncludeThrough{TableName: "links", SourceKey: "post_id", ForeignKey: "target_id"},
+				},
+				"topics": {
+					Kind:    BelongsToMany,
+					Table:   "topics",
+					Through: &IncludeThrough{TableName: "links", SourceKey: "post_id", ForeignKey: "target_id"},
+				},
+			},
+		},
+		"comments": Model{
+			Associations: map[string]Association{
+				"author": {Kind: BelongsTo, Table: "users", SourceKey: "author_id"},
+			},
+		},
+	}
+	builder, _ := New(BuilderConfig{Models: models, MaxIncludeDepth: 2})
+	tests := []struct {
+		name    string
+		filter  Filter
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "same table at two levels",
+			filter: Filter{
+				From: "posts",
+				Include: []Include{
+					{Association: "author", Attributes: []interface{}{"id"}},
+					{Association: "comments", Attributes: []interface{}{"id"}, Include: []Include{{Association: "author", Attributes: []interface{}{"id"}}}},
+				},
+				Order: []interface{}{"comments.author.name"},
+			},
+			want: `SELECT posts.*, author.id AS "author.id", comments.id AS "comments.id", comments__author.id AS "comments.author.id" FROM posts ` +
+				"LEFT JOIN users AS author ON posts.author_id = author.id " +
+				"LEFT JOIN comments ON posts.id = comments.post_id " +
+				"LEFT JOIN users AS comments__author ON comments.author_id = comments__author.id " +
+				"WHERE (1=1) ORDER BY comments__author.name ASC",
+		},
+		{
+			name: "nested where",
+			filter: Filter{
+				From: "posts",
+				Include: []Include{
+					{Association: "comments", Attributes: []interface{}{"id"}, Include: []Include{{Association: "author", Attributes: []interface{}{"id"}, Where: map[string]interface{}{"banned": false}}}},
+				},
+			},
+			want: `SELECT posts.*, comments.id AS "comments.id", comments__author.id AS "comments.author.id" FROM posts ` +
+				"LEFT JOIN comments ON posts.id = comments.post_id " +
+				"LEFT JOIN users AS comments__author ON comments.author_id = comments__author.id AND ((comments__author.banned = ?)) " +
+				"WHERE (1=1)",
+		},
+		{
+			name: "order path not included",
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Association: "comments", Attributes: []interface{}{"id"}}},
+				Order:   []interface{}{"comments.author.name"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too deep",
+			filter: Filter{
+				From: "posts",
+				Include: []Include{
+					{Association: "comments", Attributes: []interface{}{"id"}, Include: []Include{{Association: "author", Attributes: []interface{}{"id"}, Include: []Include{{Table: "orgs", Attributes: []interface{}{"id"}}}}}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate include",
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Association: "author", Attributes: []interface{}{"id"}}, {Association: "author", Attributes: []interface{}{"id"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shared joint table",
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Association: "tags", Attributes: []interface{}{"id"}}, {Association: "topics", Attributes: []interface{}{"id"}}},
+			},
+			want: `SELECT posts.*, tags.id AS "tags.id", topics.id AS "topics.id" FROM posts ` +
+				"LEFT JOIN links AS tags__links ON posts.id = tags__links.post_id LEFT JOIN tags ON tags__links.target_id = tags.id " +
+				"LEFT JOIN links AS topics__links ON posts.id = topics__links.post_id LEFT JOIN topics ON topics__links.target_id = topics.id " +
+				"WHERE (1=1)",
+		},
+		{
+			name: "main table alias",
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Table: "posts", Attributes: []interface{}{"id"}, SourceKey: "parent_id", ForeignKey: "id"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "main table with alias",
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Table: "posts", Attributes: []interface{}{"id"}, As: "parent", SourceKey: "parent_id", ForeignKey: "id"}},
+			},
+			want: `SELECT posts.*, parent.id AS "parent.id" FROM posts LEFT JOIN posts AS parent ON posts.parent_id = parent.id WHERE (1=1)`,
+		},
+		{
+			name: "alias used at another level",
+			filter: Filter{
+				From: "posts",
+				Include: []Include{
+					{Association: "comments", Attributes: []interface{}{"id"}, Include: []Include{{Association: "author", Attributes: []interface{}{"id"}}}},
+					{Association: "author", As: "comments__author", Attributes: []interface{}{"id"}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.Build(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_includeOptions(t *testing.T) {
+	models := Models{
+		"posts": Model{
+			Associations: map[string]Association{
+				"author":   {Kind: BelongsTo, Table: "users", SourceKey: "author_id"},
+				"comments": {Kind: HasMany, Table: "comments", ForeignKey: "post_id"},
+			},
+		},
+		"comments": Model{
+			Associations: map[string]Association{
+				"author": {Kind: BelongsTo, Table: "users", SourceKey: "author_id"},
+			},
+		},
+	}
+	tests := []struct {
+		name   string
+		config BuilderConfig
+		filter Filter
+		want   string
+	}{
+		{
+			name:   "attributes, as and required",
+			config: BuilderConfig{Models: models},
+			filter: Filter{
+				From:       "posts",
+				Attributes: []interface{}{"id"},
+				Include: []Include{
+					{Association: "author", As: "writer", Required: true, Attributes: []interface{}{"name"}},
+					{Association: "comments", Attributes: []interface{}{"id"}, Include: []Include{{Association: "author", Attributes: []interface{}{"id", "name"}}}},
+				},
+			},
+			want: `SELECT posts.id AS id, writer.name AS "writer.name", comments.id AS "comments.id", comments__author.id AS "comments.author.id", comments__author.name AS "comments.author.name" FROM posts ` +
+				"JOIN users AS writer ON posts.author_id = writer.id " +
+				"LEFT JOIN comments ON posts.id = comments.post_id " +
+				"LEFT JOIN users AS comments__author ON comments.author_id = comments__author.id " +
+				"WHERE (1=1)",
+		},
+		{
+			name:   "quoted",
+			config: BuilderConfig{Models: models, Dialect: MySQL},
+			filter: Filter{
+				From:    "posts",
+				Include: []Include{{Association: "author", Attributes: []interface{}{"name"}}},
+			},
+			want: "SELECT `posts`.*, `author`.`name` AS `author.name` FROM `posts` LEFT JOIN `users` AS `author` ON `posts`.`author_id` = `author`.`id` WHERE (1=1)",
+		},
+		{
+			name: "required through",
+			filter: Filter{
+				From: "posts",
+				Include: []Include{{
+					Table:      "tags",
+					SourceKey:  "id",
+					ForeignKey: "id",
+					Through:    &IncludeThrough{TableName: "post_tags", SourceKey: "post_id", ForeignKey: "tag_id"},
+					Required:   true,
+					Where:      map[string]interface{}{"label": "go"},
+					Attributes: []interface{}{"id"},
+				}},
+			},
+			want: `SELECT posts.*, tags.id AS "tags.id" FROM posts JOIN post_tags AS tags__post_tags ON posts.id = tags__post_tags.post_id JOIN tags ON tags__post_tags.tag_id = tags.id WHERE ((tags.label = ?)) AND (1=1)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, _ := New(tt.config)
+			im, err := builder.Build(tt.filter)
+			if err != nil {
+				t.Fatalf("Builder.Build() error = %v", err)
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package goquery
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// notExpr negate a condition, the result is always wrapped in parentheses so
+// it composes with AND / OR without relying on operator precedence
+type notExpr struct {
+	cond sq.Sqlizer
+}
+
+func (e notExpr) ToSql() (string, []interface{}, error) {
+	sql, args, err := e.cond.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	if sql == "" {
+		// an empty condition is always true
+		sql = "(1=1)"
+	}
+	return fmt.Sprintf("(NOT (%s))", sql), args, nil
+}
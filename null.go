@@ -0,0 +1,107 @@
+package goquery
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// isNull reports whether v renders as SQL NULL
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	// a nil pointer valuer would panic in Value
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return true
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return err == nil && value == nil
+	}
+	return false
+}
+
+// splitNulls copy the non-null elements of list, reporting whether any null
+// element was dropped
+func splitNulls(list interface{}) ([]interface{}, bool) {
+	rv := reflect.ValueOf(list)
+	values := []interface{}{}
+	hasNull := false
+	for i := 0; i < rv.Len(); i++ {
+		v := rv.Index(i).Interface()
+		if isNull(v) {
+			hasNull = true
+			continue
+		}
+		values = append(values, v)
+	}
+	return values, hasNull
+}
+
+// isConds build `IS [NOT] NULL|TRUE|FALSE` conditions for `$is` / `$isNot`.
+// Dialects with Dialect.NumericBool compare with 1 and 0 instead
+func isConds(m []entry, not bool, dialect *Dialect) ([]sq.Sqlizer, error) {
+	opr := "IS"
+	if not {
+		opr = "IS NOT"
+	}
+	conds := []sq.Sqlizer{}
+	for _, e := range m {
+		k, v := e.key, e.value
+		var value string
+		switch t := v.(type) {
+		case bool:
+			{
+				if dialect.NumericBool {
+					bit := 0
+					if t {
+						bit = 1
+					}
+					if not {
+						conds = append(conds, sq.Expr(fmt.Sprintf("(%s <> %d OR %s IS NULL)", k, bit, k)))
+					} else {
+						conds = append(conds, sq.Expr(fmt.Sprintf("%s = %d", k, bit)))
+					}
+					continue
+				}
+				value = "FALSE"
+				if t {
+					value = "TRUE"
+				}
+			}
+		default:
+			{
+				if !isNull(v) {
+					return nil, errors.New("invalid operand, $is expects null or bool")
+				}
+				value = "NULL"
+			}
+		}
+		cond := sq.Expr(fmt.Sprintf("%s %s %s", k, opr, value))
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// isNullConds build `IS [NOT] NULL` conditions for `$isNull` / `$notNull`,
+// the operand selects between the two forms
+func isNullConds(m []entry, not bool) ([]sq.Sqlizer, error) {
+	conds := []sq.Sqlizer{}
+	for _, e := range m {
+		k, v := e.key, e.value
+		flag, ok := v.(bool)
+		if !ok {
+			return nil, errors.New("invalid operand, $isNull expects bool")
+		}
+		if flag != not {
+			conds = append(conds, sq.Eq{k: nil})
+		} else {
+			conds = append(conds, sq.NotEq{k: nil})
+		}
+	}
+	return conds, nil
+}
@@ -1 +1,251 @@
 package goquery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Nulls placement of NULL values in an order
+type Nulls string
+
+const (
+	// NullsDefault database default, NULL sorts last in ascending order on
+	// Postgres and Oracle and first on MySQL, SQLite and SQL Server
+	NullsDefault Nulls = ""
+	// NullsFirst `NULLS FIRST`
+	NullsFirst Nulls = "first"
+	// NullsLast `NULLS LAST`
+	NullsLast Nulls = "last"
+)
+
+// OrderTerm a single ORDER BY term. Field is a column of the main table or
+// `path.column` of an include, e.g. `author.name` or `comments.author.name`
+type OrderTerm struct {
+	Field   string `json:"field"`
+	Desc    bool   `json:"desc,omitempty"`
+	Nulls   Nulls  `json:"nulls,omitempty"`
+	Collate string `json:"collate,omitempty"`
+}
+
+var (
+	orderField   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+	orderCollate = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ParseOrder parse an order term from
+//
+//	"-created_at"
+//	"name DESC NULLS LAST"
+//	"name COLLATE nocase ASC"
+//	[]interface{}{"name", "DESC", "NULLS LAST"}
+//
+// or return an OrderTerm as is. A leading `-` sorts descending, `+` ascending
+func ParseOrder(v interface{}) (OrderTerm, error) {
+	switch t := v.(type) {
+	case OrderTerm:
+		{
+			return t, validateOrder(t)
+		}
+	case *OrderTerm:
+		{
+			if t == nil {
+				return OrderTerm{}, errors.New("invalid order <nil>")
+			}
+			return *t, validateOrder(*t)
+		}
+	case string:
+		{
+			parts := strings.Fields(t)
+			if len(parts) == 0 {
+				return OrderTerm{}, fmt.Errorf("invalid order %q", t)
+			}
+			return parseOrderParts(parts[0], parts[1:])
+		}
+	}
+	rv := reflect.ValueOf(v)
+	if kind := rv.Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return OrderTerm{}, fmt.Errorf("invalid order %v", v)
+	}
+	parts := []string{}
+	for i := 0; i < rv.Len(); i++ {
+		str, ok := rv.Index(i).Interface().(string)
+		if !ok {
+			return OrderTerm{}, fmt.Errorf("invalid order %v", v)
+		}
+		parts = append(parts, strings.Fields(str)...)
+	}
+	if len(parts) == 0 {
+		return OrderTerm{}, fmt.Errorf("invalid order %v", v)
+	}
+	return parseOrderParts(parts[0], parts[1:])
+}
+
+// parseOrderParts parse `[-|+]field [COLLATE name] [ASC|DESC] [NULLS FIRST|LAST]`
+func parseOrderParts(field string, mods []string) (OrderTerm, error) {
+	term := OrderTerm{}
+	signed := false
+	if strings.HasPrefix(field, "-") {
+		term.Desc = true
+		signed = true
+		field = field[1:]
+	} else if strings.HasPrefix(field, "+") {
+		signed = true
+		field = field[1:]
+	}
+	term.Field = field
+	// modifiers in SQL order, each at most once
+	stage := 0
+	for i := 0; i < len(mods); i++ {
+		switch word := strings.ToUpper(mods[i]); {
+		case word == "COLLATE" && stage < 1 && i+1 < len(mods):
+			{
+				i++
+				term.Collate = mods[i]
+				stage = 1
+			}
+		case (word == "ASC" || word == "DESC") && stage < 2 && !signed:
+			{
+				term.Desc = word == "DESC"
+				stage = 2
+			}
+		case word == "NULLS" && stage < 3 && i+1 < len(mods):
+			{
+				i++
+				switch strings.ToUpper(mods[i]) {
+				case "FIRST":
+					{
+						term.Nulls = NullsFirst
+					}
+				case "LAST":
+					{
+						term.Nulls = NullsLast
+					}
+				default:
+					{
+						return OrderTerm{}, fmt.Errorf("invalid nulls placement %q", mods[i])
+					}
+				}
+				stage = 3
+			}
+		default:
+			{
+				return OrderTerm{}, fmt.Errorf("invalid order modifier %q", mods[i])
+			}
+		}
+	}
+	return term, validateOrder(term)
+}
+
+func validateOrder(term OrderTerm) error {
+	if !orderField.MatchString(term.Field) {
+		return fmt.Errorf("invalid order field %q", term.Field)
+	}
+	if term.Nulls != NullsDefault && term.Nulls != NullsFirst && term.Nulls != NullsLast {
+		return fmt.Errorf("invalid nulls placement %q", term.Nulls)
+	}
+	if term.Collate != "" && !orderCollate.MatchString(term.Collate) {
+		return fmt.Errorf("invalid collation %q", term.Collate)
+	}
+	return nil
+}
+
+// orderTerms parse the order of filter and check it against
+// BuilderConfig.OrderColumns and the includes of filter
+func (b *Builder) orderTerms(filter Filter) ([]OrderTerm, error) {
+	terms := []OrderTerm{}
+	for _, v := range filter.Order {
+		term, err := ParseOrder(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b.config.OrderColumns) > 0 && !containsString(b.config.OrderColumns, term.Field) {
+			return nil, fmt.Errorf("order by %s is not allowed", term.Field)
+		}
+		if alias, _ := splitOrderField(term.Field); alias != "" && !orderPathIncluded(filter, alias) {
+			return nil, fmt.Errorf("order by %s: %s is not included", term.Field, alias)
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// splitOrderField split `comments.author.name` into the include alias
+// `comments__author` and the column `name`
+func splitOrderField(field string) (string, string) {
+	i := strings.LastIndex(field, ".")
+	if i < 0 {
+		return "", field
+	}
+	return strings.Replace(field[:i], ".", "__", -1), field[i+1:]
+}
+
+// orderPathIncluded whether alias is the main table or the alias of one of
+// the joined includes of filter
+func orderPathIncluded(filter Filter, alias string) bool {
+	return tableAlias(filter.From) == alias || includeAliases("", filter.Include)[alias]
+}
+
+func includeAliases(parent string, includes []Include) map[string]bool {
+	aliases := map[string]bool{}
+	for _, include := range includes {
+		if include.Separate {
+			continue
+		}
+		alias := aliasPath(parent, include.alias())
+		aliases[alias] = true
+		for child := range includeAliases(alias, include.Include) {
+			aliases[child] = true
+		}
+	}
+	return aliases
+}
+
+// orderColumn qualified and quoted column of an order term
+func (b *builderContext) orderColumn(term OrderTerm) string {
+	alias, column := splitOrderField(term.Field)
+	if alias == "" {
+		alias = b.tableName
+	}
+	return b.toFullName(alias, column)
+}
+
+// orderBy render order terms, NULLS FIRST / LAST is emulated with a CASE
+// term on dialects without it
+func (b *builderContext) orderBy(bs sq.SelectBuilder, terms []OrderTerm) sq.SelectBuilder {
+	dialect := b.builder.dialect()
+	orders := []string{}
+	for _, term := range terms {
+		column := b.orderColumn(term)
+		expr := column
+		if term.Collate != "" {
+			collate := term.Collate
+			if dialect.QuoteCollation {
+				collate = dialect.QuoteIdent(collate)
+			}
+			expr = fmt.Sprintf("%s COLLATE %s", expr, collate)
+		}
+		dir := "ASC"
+		if term.Desc {
+			dir = "DESC"
+		}
+		expr = fmt.Sprintf("%s %s", expr, dir)
+		if term.Nulls != NullsDefault {
+			if dialect.NullsOrder {
+				expr = fmt.Sprintf("%s NULLS %s", expr, strings.ToUpper(string(term.Nulls)))
+			} else {
+				first, rest := 1, 0
+				if term.Nulls == NullsFirst {
+					first, rest = 0, 1
+				}
+				orders = append(orders, fmt.Sprintf("CASE WHEN %s IS NULL THEN %d ELSE %d END", column, first, rest))
+			}
+		}
+		orders = append(orders, expr)
+	}
+	return bs.OrderBy(orders...)
+}
@@ -0,0 +1,128 @@
+package goquery
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    OrderTerm
+		wantErr bool
+	}{
+		{name: "field", v: "name", want: OrderTerm{Field: "name"}},
+		{name: "minus", v: "-created_at", want: OrderTerm{Field: "created_at", Desc: true}},
+		{name: "direction", v: "name desc", want: OrderTerm{Field: "name", Desc: true}},
+		{
+			name: "all modifiers",
+			v:    "author.name COLLATE nocase DESC NULLS LAST",
+			want: OrderTerm{Field: "author.name", Desc: true, Nulls: NullsLast, Collate: "nocase"},
+		},
+		{name: "list", v: []interface{}{"name", "DESC"}, want: OrderTerm{Field: "name", Desc: true}},
+		{name: "string list", v: []string{"name", "NULLS FIRST"}, want: OrderTerm{Field: "name", Nulls: NullsFirst}},
+		{name: "term", v: OrderTerm{Field: "name"}, want: OrderTerm{Field: "name"}},
+		{name: "injection", v: "name; drop table users", wantErr: true},
+		{name: "sign and direction", v: "-name ASC", wantErr: true},
+		{name: "modifier order", v: "name NULLS LAST DESC", wantErr: true},
+		{name: "invalid collation", v: "name COLLATE 'x'", wantErr: true},
+		{name: "invalid term field", v: OrderTerm{Field: "(select 1)"}, wantErr: true},
+		{name: "not a string", v: []interface{}{"name", 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOrder(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_order(t *testing.T) {
+	order := []interface{}{"-created_at", []interface{}{"author.name", "ASC", "NULLS LAST"}}
+	author := []Include{{Table: "author", SourceKey: "author_id", ForeignKey: "id", Attributes: []interface{}{"name"}}}
+	tests := []struct {
+		name    string
+		config  BuilderConfig
+		include []Include
+		order   []interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "generic",
+			include: author,
+			order:   order,
+			want:    `SELECT posts.*, author.name AS "author.name" FROM posts LEFT JOIN author ON posts.author_id = author.id WHERE (1=1) ORDER BY posts.created_at DESC, CASE WHEN author.name IS NULL THEN 1 ELSE 0 END, author.name ASC`,
+		},
+		{
+			name:    "postgres",
+			config:  BuilderConfig{Dialect: Postgres},
+			include: author,
+			order:   order,
+			want:    `SELECT "posts".*, "author"."name" AS "author.name" FROM "posts" LEFT JOIN "author" ON "posts"."author_id" = "author"."id" WHERE (1=1) ORDER BY "posts"."created_at" DESC, "author"."name" ASC NULLS LAST`,
+		},
+		{
+			name:   "mysql",
+			config: BuilderConfig{Dialect: MySQL},
+			order:  []interface{}{"name NULLS FIRST"},
+			want:   "SELECT * FROM `posts` WHERE (1=1) ORDER BY CASE WHEN `posts`.`name` IS NULL THEN 0 ELSE 1 END, `posts`.`name` ASC",
+		},
+		{
+			name:   "collation",
+			config: BuilderConfig{Dialect: Postgres},
+			order:  []interface{}{"name COLLATE C"},
+			want:   `SELECT * FROM "posts" WHERE (1=1) ORDER BY "posts"."name" COLLATE "C" ASC`,
+		},
+		{
+			name:   "allowed column",
+			config: BuilderConfig{OrderColumns: []string{"created_at"}},
+			order:  []interface{}{"-created_at"},
+			want:   "SELECT * FROM posts WHERE (1=1) ORDER BY posts.created_at DESC",
+		},
+		{
+			name:    "column not allowed",
+			config:  BuilderConfig{OrderColumns: []string{"created_at"}},
+			order:   []interface{}{"password"},
+			wantErr: true,
+		},
+		{
+			name:  "main table path",
+			order: []interface{}{"posts.title"},
+			want:  "SELECT * FROM posts WHERE (1=1) ORDER BY posts.title ASC",
+		},
+		{
+			name:    "association not included",
+			order:   []interface{}{"author.name"},
+			wantErr: true,
+		},
+		{
+			name:    "injection",
+			order:   []interface{}{"1; DROP TABLE posts"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, _ := New(tt.config)
+			im, err := builder.Build(Filter{From: "posts", Include: tt.include, Order: tt.order})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package goquery
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// OrderedMap condition map that keeps insertion order. Plain maps are
+// rendered in sorted key order, OrderedMap can be used anywhere a condition
+// map is accepted when the caller's order should be kept instead
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap create an empty ordered map
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		values: map[string]interface{}{},
+	}
+}
+
+// Set set key to value, a new key is appended, an existing key keeps its
+// position
+func (m *OrderedMap) Set(key string, value interface{}) *OrderedMap {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+	return m
+}
+
+// Get value of key
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Keys keys in insertion order
+func (m *OrderedMap) Keys() []string {
+	return append([]string{}, m.keys...)
+}
+
+// Len number of keys
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// entry a key / value pair of a condition map
+type entry struct {
+	key   string
+	value interface{}
+}
+
+// mapEntries entries of a condition map, sorted by key for plain maps and in
+// insertion order for *OrderedMap. ok is false when v is not a map
+func mapEntries(v interface{}) (entries []entry, ok bool, err error) {
+	if om, isOrdered := v.(*OrderedMap); isOrdered {
+		if om == nil {
+			return nil, false, nil
+		}
+		for _, k := range om.keys {
+			entries = append(entries, entry{k, om.values[k]})
+		}
+		return entries, true, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, false, nil
+	}
+	if rv.Type().Key().Kind() != reflect.String {
+		return nil, true, errors.New("invalid syntax, key must be string")
+	}
+	iter := rv.MapRange()
+	for iter.Next() {
+		entries = append(entries, entry{iter.Key().String(), iter.Value().Interface()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	return entries, true, nil
+}
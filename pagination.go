@@ -0,0 +1,66 @@
+package goquery
+
+import "fmt"
+
+// PaginationPolicy limit and offset policy, zero values disable a rule
+type PaginationPolicy struct {
+	// DefaultLimit limit used when Filter.Limit is nil
+	DefaultLimit uint64
+	// MaxLimit largest accepted limit, it is also used as the limit when
+	// Filter.Limit is nil and there is no DefaultLimit
+	MaxLimit uint64
+	// ClampLimit lower limits above MaxLimit instead of failing
+	ClampLimit bool
+	// MaxOffset largest accepted offset
+	MaxOffset uint64
+}
+
+// PaginationError limit or offset of a filter exceeds the configured maximum
+type PaginationError struct {
+	// Field "limit" or "offset"
+	Field string
+	Value uint64
+	Max   uint64
+}
+
+func (e *PaginationError) Error() string {
+	return fmt.Sprintf("%s %d exceeds maximum %d", e.Field, e.Value, e.Max)
+}
+
+// pagination effective limit and offset of filter. An explicit zero limit is
+// kept, a nil limit means no limit
+func (b *Builder) pagination(filter Filter) (*uint64, uint64, error) {
+	policy := b.config.Pagination
+	var limit *uint64
+	if filter.Limit != nil {
+		v := *(filter.Limit)
+		limit = &v
+	} else if policy.DefaultLimit != 0 {
+		v := policy.DefaultLimit
+		limit = &v
+	}
+	if policy.MaxLimit != 0 {
+		switch {
+		case limit == nil:
+			{
+				v := policy.MaxLimit
+				limit = &v
+			}
+		case *limit > policy.MaxLimit:
+			{
+				if !policy.ClampLimit {
+					return nil, 0, &PaginationError{Field: "limit", Value: *limit, Max: policy.MaxLimit}
+				}
+				*limit = policy.MaxLimit
+			}
+		}
+	}
+	var offset uint64
+	if filter.Offset != nil {
+		offset = *(filter.Offset)
+	}
+	if policy.MaxOffset != 0 && offset > policy.MaxOffset {
+		return nil, 0, &PaginationError{Field: "offset", Value: offset, Max: policy.MaxOffset}
+	}
+	return limit, offset, nil
+}
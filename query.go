@@ -0,0 +1,261 @@
+package goquery
+
+import "fmt"
+
+// Query parsed Filter, the conditions can be inspected or rewritten before
+// the query is rendered
+type Query struct {
+	Filter Filter
+	// Table alias of Filter.From that where fields belong to
+	Table   string
+	Where   Node
+	Include []*IncludeQuery
+}
+
+// IncludeQuery parsed Include
+type IncludeQuery struct {
+	// Include include with the table and keys of its association filled in
+	Include Include
+	// Association nil when the include is not given by association
+	Association *Association
+	// Alias unique alias of the include path, e.g. `comments__author`
+	Alias string
+	// Path dotted include path, e.g. `comments.author`, prefixing the result
+	// aliases of the selected columns
+	Path string
+	// Parent alias of the including include, empty for the main table
+	Parent string
+	// Where nil when Include.Where is empty
+	Where Node
+	// Through alias of the joint table, empty without Include.Through
+	Through string
+	// ThroughWhere nil when there are no conditions on the joint table
+	ThroughWhere Node
+	// Children parsed nested includes
+	Children []*IncludeQuery
+}
+
+// defaultIncludeDepth nesting depth of includes when
+// BuilderConfig.MaxIncludeDepth is zero
+const defaultIncludeDepth = 5
+
+// aliasPath alias of an include named name below the include parent
+func aliasPath(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "__" + name
+}
+
+// Parse parse the conditions of filter
+func (b *Builder) Parse(filter Filter) (*Query, error) {
+	ctx := builderContext{
+		builder: b,
+		rel:     OpAnd,
+	}
+	_, tableAlias, err := ctx.buildFrom(filter.From)
+	if err != nil {
+		return nil, err
+	}
+	ctx.tableName = tableAlias
+	where, err := ctx.parseWhere(filter.Where)
+	if err != nil {
+		return nil, err
+	}
+	query := &Query{
+		Filter: filter,
+		Table:  tableAlias,
+		Where:  where,
+	}
+	// aliases must be unique across the whole statement
+	aliases := map[string]bool{tableAlias: true}
+	query.Include, err = ctx.parseIncludes(filter.From, nil, filter.Include, 1, aliases)
+	if err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// parseIncludes parse the includes of table, parent is the including
+// include or nil for the main table. aliases holds the table aliases in use
+func (b *builderContext) parseIncludes(table string, parent *IncludeQuery, includes []Include, depth int, aliases map[string]bool) ([]*IncludeQuery, error) {
+	if len(includes) == 0 {
+		return nil, nil
+	}
+	maxDepth := b.builder.config.MaxIncludeDepth
+	if maxDepth == 0 {
+		maxDepth = defaultIncludeDepth
+	}
+	if depth > maxDepth {
+		return nil, fmt.Errorf("includes nested deeper than %d levels", maxDepth)
+	}
+	list := []*IncludeQuery{}
+	for _, include := range includes {
+		include, assoc, err := b.builder.resolveInclude(table, include)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.checkInclude(include); err != nil {
+			return nil, err
+		}
+		iq := &IncludeQuery{
+			Include:     include,
+			Association: assoc,
+			Alias:       include.alias(),
+			Path:        include.alias(),
+		}
+		if parent != nil {
+			iq.Parent = parent.Alias
+			iq.Alias = aliasPath(parent.Alias, iq.Alias)
+			iq.Path = parent.Path + "." + iq.Path
+		}
+		if include.Separate {
+			if include.Required {
+				return nil, fmt.Errorf("include %s: separate includes cannot be required", iq.Path)
+			}
+			if parent != nil && !parent.Include.Separate {
+				return nil, fmt.Errorf("include %s: separate includes must be below the main table or a separate include", iq.Path)
+			}
+		}
+		if aliases[iq.Alias] {
+			return nil, fmt.Errorf("include %s: alias %s is already used", iq.Path, iq.Alias)
+		}
+		aliases[iq.Alias] = true
+		if len(include.Where) > 0 {
+			nb := b.inherit()
+			nb.tableName = iq.Alias
+			iq.Where, err = nb.parseWhere(include.Where)
+			if err != nil {
+				return nil, err
+			}
+			if err := b.checkFields(iq.Where); err != nil {
+				return nil, fmt.Errorf("include %s: %v", iq.Path, err)
+			}
+		}
+		if th := include.Through; th != nil {
+			iq.Through = aliasPath(iq.Alias, tableAlias(th.TableName))
+			if aliases[iq.Through] {
+				return nil, fmt.Errorf("include %s: alias %s is already used", iq.Path, iq.Through)
+			}
+			aliases[iq.Through] = true
+			if len(th.Where) > 0 {
+				nb := b.inherit()
+				nb.tableName = iq.Through
+				iq.ThroughWhere, err = nb.parseWhere(th.Where)
+				if err != nil {
+					return nil, err
+				}
+				if err := b.checkFields(iq.ThroughWhere); err != nil {
+					return nil, fmt.Errorf("include %s: %v", iq.Path, err)
+				}
+			}
+		}
+		iq.Children, err = b.parseIncludes(include.Table, iq, include.Include, depth+1, aliases)
+		if err != nil {
+			return nil, err
+		}
+		if !include.Separate {
+			// an inner joined child filters the rows of its parent
+			for _, child := range iq.Children {
+				if child.Include.Required {
+					iq.Include.Required = true
+				}
+			}
+		}
+		list = append(list, iq)
+	}
+	return list, nil
+}
+
+// includes all includes of the query, parents before their children
+func (q *Query) includes() []*IncludeQuery {
+	return flattenIncludes(q.Include)
+}
+
+// joinedIncludes includes that are joined, separate includes and their
+// children are left out
+func joinedIncludes(includes []*IncludeQuery) []*IncludeQuery {
+	list := []*IncludeQuery{}
+	for _, iq := range includes {
+		if iq.Include.Separate {
+			continue
+		}
+		list = append(list, iq)
+		list = append(list, joinedIncludes(iq.Children)...)
+	}
+	return list
+}
+
+func flattenIncludes(includes []*IncludeQuery) []*IncludeQuery {
+	list := []*IncludeQuery{}
+	for _, iq := range includes {
+		list = append(list, iq)
+		list = append(list, flattenIncludes(iq.Children)...)
+	}
+	return list
+}
+
+// Inspect call Inspect on every condition tree of the query
+func (q *Query) Inspect(f func(Node) bool) {
+	Inspect(q.Where, f)
+	for _, iq := range q.includes() {
+		if iq.ThroughWhere != nil {
+			Inspect(iq.ThroughWhere, f)
+		}
+		if iq.Where != nil {
+			Inspect(iq.Where, f)
+		}
+	}
+}
+
+// Rewrite call Rewrite on every condition tree of the query, replacing them
+// in place
+func (q *Query) Rewrite(f func(Node) (Node, error)) error {
+	where, err := Rewrite(q.Where, f)
+	if err != nil {
+		return err
+	}
+	if where == nil {
+		where = And{}
+	}
+	q.Where = where
+	for _, iq := range q.includes() {
+		if iq.ThroughWhere != nil {
+			if iq.ThroughWhere, err = Rewrite(iq.ThroughWhere, f); err != nil {
+				return err
+			}
+		}
+		if iq.Where != nil {
+			if iq.Where, err = Rewrite(iq.Where, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toMany whether the include can match more than one row per parent row.
+// Includes without association are assumed to match one row unless they
+// have a joint table
+func (iq *IncludeQuery) toMany() bool {
+	if iq.Association != nil {
+		return iq.Association.Kind == HasMany || iq.Association.Kind == BelongsToMany
+	}
+	return iq.Include.Through != nil
+}
+
+// subQuery whether the main table is paginated in a derived table
+func (b *Builder) subQuery(query *Query, limit *uint64, offset uint64) bool {
+	if query.Filter.SubQuery {
+		return true
+	}
+	if limit == nil && offset == 0 {
+		return false
+	}
+	for _, iq := range joinedIncludes(query.Include) {
+		if iq.toMany() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,531 @@
+package goquery
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryParams names of the query string parameters read by ParseQuery,
+// empty names use the defaults `where`, `order`, `limit`, `offset`,
+// `attributes`, `include`, `after` and `before`
+type QueryParams struct {
+	Where      string
+	Order      string
+	Limit      string
+	Offset     string
+	Attributes string
+	Include    string
+	After      string
+	Before     string
+}
+
+func (p QueryParams) withDefaults() QueryParams {
+	defaults := QueryParams{
+		Where:      "where",
+		Order:      "order",
+		Limit:      "limit",
+		Offset:     "offset",
+		Attributes: "attributes",
+		Include:    "include",
+		After:      "after",
+		Before:     "before",
+	}
+	if p.Where == "" {
+		p.Where = defaults.Where
+	}
+	if p.Order == "" {
+		p.Order = defaults.Order
+	}
+	if p.Limit == "" {
+		p.Limit = defaults.Limit
+	}
+	if p.Offset == "" {
+		p.Offset = defaults.Offset
+	}
+	if p.Attributes == "" {
+		p.Attributes = defaults.Attributes
+	}
+	if p.Include == "" {
+		p.Include = defaults.Include
+	}
+	if p.After == "" {
+		p.After = defaults.After
+	}
+	if p.Before == "" {
+		p.Before = defaults.Before
+	}
+	return p
+}
+
+// QueryError error of query string decoding
+type QueryError struct {
+	Param string
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Param, e.Err)
+}
+
+// ParseQuery decode a filter from bracket-notation query parameters, e.g.
+//
+//	?where[status][$in][]=a&where[age][$gte]=18&order=-created_at
+//	&limit=20&offset=40&attributes=id,name&include=author
+//
+// Cursors of keyset pagination are read from `after` and `before`.
+// Condition values are strings, except for operands of list operators,
+// which are always lists, and of `$isNull`, `$notNull`, `$is` and `$isNot`,
+// which are parsed as bool or null. Operators are recognized with
+// BuilderConfig.OperatorMapping. Filter.From is left for the caller to set.
+// Includes are given either as association names or in bracket notation,
+// e.g. `include[0][table]=posts&include[0][sourceKey]=id`
+func (b *Builder) ParseQuery(values url.Values) (Filter, error) {
+	names := b.config.QueryParams.withDefaults()
+	filter := Filter{}
+	where := map[string]interface{}{}
+	includes := map[string]interface{}{}
+
+	keys := []string{}
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		base, path, err := splitQueryKey(key)
+		if err != nil {
+			return filter, &QueryError{Param: key, Err: err}
+		}
+		switch base {
+		case names.Where:
+			{
+				if len(path) == 0 {
+					return filter, &QueryError{Param: key, Err: errors.New("expected where[field]")}
+				}
+				if err := setQueryValue(where, path, values[key]); err != nil {
+					return filter, &QueryError{Param: key, Err: err}
+				}
+			}
+		case names.Include:
+			{
+				if len(path) == 0 || (len(path) == 1 && path[0] == "") {
+					for _, name := range splitQueryList(values[key]) {
+						filter.Include = append(filter.Include, Include{Association: name})
+					}
+					continue
+				}
+				if err := setQueryValue(includes, path, values[key]); err != nil {
+					return filter, &QueryError{Param: key, Err: err}
+				}
+			}
+		case names.Order:
+			{
+				for _, term := range splitQueryList(values[key]) {
+					order, err := ParseOrder(term)
+					if err != nil {
+						return filter, &QueryError{Param: key, Err: err}
+					}
+					filter.Order = append(filter.Order, order)
+				}
+			}
+		case names.Attributes:
+			{
+				for _, attr := range splitQueryList(values[key]) {
+					filter.Attributes = append(filter.Attributes, attr)
+				}
+			}
+		case names.Limit:
+			{
+				if filter.Limit, err = queryUint(values[key]); err != nil {
+					return filter, &QueryError{Param: key, Err: err}
+				}
+			}
+		case names.Offset:
+			{
+				if filter.Offset, err = queryUint(values[key]); err != nil {
+					return filter, &QueryError{Param: key, Err: err}
+				}
+			}
+		case names.After, names.Before:
+			{
+				if len(values[key]) != 1 {
+					return filter, &QueryError{Param: key, Err: errors.New("parameter given more than once")}
+				}
+				if base == names.After {
+					filter.After = values[key][0]
+				} else {
+					filter.Before = values[key][0]
+				}
+			}
+		}
+	}
+
+	if len(where) > 0 {
+		v, err := b.coerceQueryValue(queryLists(where))
+		if err != nil {
+			return filter, &QueryError{Param: names.Where, Err: err}
+		}
+		filter.Where = v.(map[string]interface{})
+	}
+	if len(includes) > 0 {
+		list, ok := queryLists(includes).([]interface{})
+		if !ok {
+			return filter, &QueryError{Param: names.Include, Err: errors.New("expected include[index][...]")}
+		}
+		for i, elem := range list {
+			param := fmt.Sprintf("%s[%d]", names.Include, i)
+			include, err := b.queryInclude(elem)
+			if err != nil {
+				return filter, &QueryError{Param: param, Err: err}
+			}
+			filter.Include = append(filter.Include, include)
+		}
+	}
+	return filter, nil
+}
+
+// splitQueryKey split `where[a][b][]` into `where` and ["a", "b", ""]
+func splitQueryKey(key string) (string, []string, error) {
+	i := strings.Index(key, "[")
+	if i < 0 {
+		return key, nil, nil
+	}
+	base := key[:i]
+	path := []string{}
+	rest := key[i:]
+	for rest != "" {
+		if rest[0] != '[' {
+			return "", nil, errors.New("malformed brackets")
+		}
+		j := strings.Index(rest, "]")
+		if j < 0 {
+			return "", nil, errors.New("malformed brackets")
+		}
+		path = append(path, rest[1:j])
+		rest = rest[j+1:]
+	}
+	for i, seg := range path {
+		if seg == "" && i != len(path)-1 {
+			return "", nil, errors.New("[] must be the last segment")
+		}
+	}
+	return base, path, nil
+}
+
+// setQueryValue store values at path, a trailing `[]` segment appends all
+// values to a list, otherwise a single value is expected
+func setQueryValue(m map[string]interface{}, path []string, values []string) error {
+	key := path[0]
+	if len(path) == 2 && path[1] == "" {
+		list, _ := m[key].([]interface{})
+		if _, exists := m[key]; exists && list == nil {
+			return errors.New("conflicting parameters")
+		}
+		for _, v := range values {
+			list = append(list, v)
+		}
+		m[key] = list
+		return nil
+	}
+	if len(path) == 1 {
+		if _, exists := m[key]; exists {
+			return errors.New("conflicting parameters")
+		}
+		if len(values) != 1 {
+			return errors.New("parameter given more than once")
+		}
+		m[key] = values[0]
+		return nil
+	}
+	child, ok := m[key].(map[string]interface{})
+	if !ok {
+		if _, exists := m[key]; exists {
+			return errors.New("conflicting parameters")
+		}
+		child = map[string]interface{}{}
+		m[key] = child
+	}
+	return setQueryValue(child, path[1:], values)
+}
+
+// queryLists turn maps whose keys are all indexes into lists ordered by
+// index
+func queryLists(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	indexes := map[int]string{}
+	for k := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 {
+			indexes = nil
+			break
+		}
+		indexes[i] = k
+	}
+	if len(indexes) == 0 {
+		converted := map[string]interface{}{}
+		for k, elem := range m {
+			converted[k] = queryLists(elem)
+		}
+		return converted
+	}
+	sorted := []int{}
+	for i := range indexes {
+		sorted = append(sorted, i)
+	}
+	sort.Ints(sorted)
+	list := []interface{}{}
+	for _, i := range sorted {
+		list = append(list, queryLists(m[indexes[i]]))
+	}
+	return list
+}
+
+// coerceQueryValue convert the string operands of operators that do not
+// take strings
+func (b *Builder) coerceQueryValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		{
+			m := map[string]interface{}{}
+			entries, _, _ := mapEntries(t)
+			for _, e := range entries {
+				k, elem := e.key, e.value
+				op, isOp := b.revOperators[k]
+				if !isOp {
+					value, err := b.coerceQueryValue(elem)
+					if err != nil {
+						return nil, err
+					}
+					m[k] = value
+					continue
+				}
+				value, err := b.coerceQueryOperand(op, elem)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", k, err)
+				}
+				m[k] = value
+			}
+			return m, nil
+		}
+	case []interface{}:
+		{
+			list := []interface{}{}
+			for _, elem := range t {
+				value, err := b.coerceQueryValue(elem)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			return list, nil
+		}
+	default:
+		{
+			return v, nil
+		}
+	}
+}
+
+func (b *Builder) coerceQueryOperand(op Op, v interface{}) (interface{}, error) {
+	str, isString := v.(string)
+	switch op {
+	case OpIn, OpNotIn, OpBetween, OpNotBetween, OpRange:
+		{
+			if isString {
+				return []interface{}{str}, nil
+			}
+			return v, nil
+		}
+	case OpIsNull, OpNotNull:
+		{
+			if !isString {
+				return nil, errors.New("expected true or false")
+			}
+			return strconv.ParseBool(str)
+		}
+	case OpIs, OpIsNot:
+		{
+			if isString && str == "null" {
+				return nil, nil
+			}
+			if !isString {
+				return nil, errors.New("expected null, true or false")
+			}
+			return strconv.ParseBool(str)
+		}
+	default:
+		{
+			return b.coerceQueryValue(v)
+		}
+	}
+}
+
+func (b *Builder) queryInclude(v interface{}) (Include, error) {
+	include := Include{}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return include, errors.New("expected include[index][key]")
+	}
+	entries, _, _ := mapEntries(m)
+	for _, e := range entries {
+		switch e.key {
+		case "association", "table", "as", "sourceKey", "foreignKey":
+			{
+				fields := map[string]*string{
+					"association": &include.Association,
+					"table":       &include.Table,
+					"as":          &include.As,
+					"sourceKey":   &include.SourceKey,
+					"foreignKey":  &include.ForeignKey,
+				}
+				str, ok := e.value.(string)
+				if !ok {
+					return include, fmt.Errorf("%s: expected string", e.key)
+				}
+				*fields[e.key] = str
+			}
+		case "through":
+			{
+				th, ok := e.value.(map[string]interface{})
+				if !ok {
+					return include, errors.New("through: expected through[key]")
+				}
+				through := &IncludeThrough{}
+				fields := map[string]*string{
+					"tableName":  &through.TableName,
+					"sourceKey":  &through.SourceKey,
+					"foreignKey": &through.ForeignKey,
+				}
+				thEntries, _, _ := mapEntries(th)
+				for _, te := range thEntries {
+					if te.key == "where" {
+						where, err := b.coerceQueryValue(te.value)
+						if err != nil {
+							return include, err
+						}
+						m, ok := where.(map[string]interface{})
+						if !ok {
+							return include, errors.New("through[where]: expected through[where][field]")
+						}
+						through.Where = m
+						continue
+					}
+					field, known := fields[te.key]
+					if !known {
+						return include, fmt.Errorf("through[%s]: unknown key", te.key)
+					}
+					str, ok := te.value.(string)
+					if !ok {
+						return include, fmt.Errorf("through[%s]: expected string", te.key)
+					}
+					*field = str
+				}
+				include.Through = through
+			}
+		case "where":
+			{
+				where, err := b.coerceQueryValue(e.value)
+				if err != nil {
+					return include, err
+				}
+				m, ok := where.(map[string]interface{})
+				if !ok {
+					return include, errors.New("where: expected where[field]")
+				}
+				include.Where = m
+			}
+		case "required", "separate":
+			{
+				fields := map[string]*bool{
+					"required": &include.Required,
+					"separate": &include.Separate,
+				}
+				str, ok := e.value.(string)
+				if !ok {
+					return include, fmt.Errorf("%s: expected true or false", e.key)
+				}
+				v, err := strconv.ParseBool(str)
+				if err != nil {
+					return include, fmt.Errorf("%s: expected true or false", e.key)
+				}
+				*fields[e.key] = v
+			}
+		case "attributes":
+			{
+				values := []string{}
+				switch t := e.value.(type) {
+				case string:
+					{
+						values = append(values, t)
+					}
+				case []interface{}:
+					{
+						for _, elem := range t {
+							str, ok := elem.(string)
+							if !ok {
+								return include, errors.New("attributes: expected list of names")
+							}
+							values = append(values, str)
+						}
+					}
+				default:
+					{
+						return include, errors.New("attributes: expected list of names")
+					}
+				}
+				for _, attr := range splitQueryList(values) {
+					include.Attributes = append(include.Attributes, attr)
+				}
+			}
+		case "include":
+			{
+				list, ok := e.value.([]interface{})
+				if !ok {
+					return include, errors.New("include: expected include[index][...]")
+				}
+				for i, elem := range list {
+					child, err := b.queryInclude(elem)
+					if err != nil {
+						return include, fmt.Errorf("include[%d]: %v", i, err)
+					}
+					include.Include = append(include.Include, child)
+				}
+			}
+		default:
+			{
+				return include, fmt.Errorf("%s: unknown key", e.key)
+			}
+		}
+	}
+	return include, nil
+}
+
+// splitQueryList values of a repeated or comma separated parameter
+func splitQueryList(values []string) []string {
+	list := []string{}
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func queryUint(values []string) (*uint64, error) {
+	if len(values) != 1 {
+		return nil, errors.New("parameter given more than once")
+	}
+	u, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("expected non-negative integer")
+	}
+	return &u, nil
+}
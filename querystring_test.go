@@ -0,0 +1,137 @@
+package goquery
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_ParseQuery(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	mapped, _ := New(BuilderConfig{
+		OperatorMapping: map[string]string{"$gte": "gte", "$in": "in"},
+		QueryParams:     QueryParams{Where: "filter", Order: "sort"},
+	})
+	var limit uint64 = 20
+	var offset uint64 = 40
+	tests := []struct {
+		name    string
+		b       *Builder
+		query   string
+		want    Filter
+		wantErr bool
+	}{
+		{
+			name:  "full",
+			b:     builder,
+			query: "where[status][$in][]=a&where[status][$in][]=b&where[age][$gte]=18&order=-created_at,name&limit=20&offset=40&attributes=id,name&include=author",
+			want: Filter{
+				Where: map[string]interface{}{
+					"status": map[string]interface{}{"$in": []interface{}{"a", "b"}},
+					"age":    map[string]interface{}{"$gte": "18"},
+				},
+				Order:      []interface{}{OrderTerm{Field: "created_at", Desc: true}, OrderTerm{Field: "name"}},
+				Limit:      &limit,
+				Offset:     &offset,
+				Attributes: []interface{}{"id", "name"},
+				Include:    []Include{{Association: "author"}},
+			},
+		},
+		{
+			name:  "indexed lists and coerced operands",
+			b:     builder,
+			query: "where[$or][1][b]=2&where[$or][0][a]=1&where[c][$in]=x&where[d][$isNull]=true&where[e][$is]=null",
+			want: Filter{
+				Where: map[string]interface{}{
+					"$or": []interface{}{
+						map[string]interface{}{"a": "1"},
+						map[string]interface{}{"b": "2"},
+					},
+					"c": map[string]interface{}{"$in": []interface{}{"x"}},
+					"d": map[string]interface{}{"$isNull": true},
+					"e": map[string]interface{}{"$is": nil},
+				},
+			},
+		},
+		{
+			name:  "bracket include",
+			b:     builder,
+			query: "include[0][table]=posts&include[0][sourceKey]=id&include[0][foreignKey]=user_id&include[0][where][draft]=false",
+			want: Filter{
+				Include: []Include{
+					{
+						Table:      "posts",
+						SourceKey:  "id",
+						ForeignKey: "user_id",
+						Where:      map[string]interface{}{"draft": "false"},
+					},
+				},
+			},
+		},
+		{
+			name:  "nested include",
+			b:     builder,
+			query: "include[0][association]=comments&include[0][include][0][association]=author",
+			want: Filter{
+				Include: []Include{
+					{Association: "comments", Include: []Include{{Association: "author"}}},
+				},
+			},
+		},
+		{
+			name:  "custom names and operator mapping",
+			b:     mapped,
+			query: "filter[id][in]=1&filter[age][gte]=18&sort=-age",
+			want: Filter{
+				Where: map[string]interface{}{
+					"id":  map[string]interface{}{"in": []interface{}{"1"}},
+					"age": map[string]interface{}{"gte": "18"},
+				},
+				Order: []interface{}{OrderTerm{Field: "age", Desc: true}},
+			},
+		},
+		{
+			name:    "order injection",
+			b:       builder,
+			query:   "order=name%3Bdrop%20table%20users",
+			wantErr: true,
+		},
+		{
+			name:    "invalid limit",
+			b:       builder,
+			query:   "limit=-1",
+			wantErr: true,
+		},
+		{
+			name:    "conflicting parameters",
+			b:       builder,
+			query:   "where[a]=1&where[a][$gt]=2",
+			wantErr: true,
+		},
+		{
+			name:    "invalid $isNull",
+			b:       builder,
+			query:   "where[a][$isNull]=maybe",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("url.ParseQuery() error = %v", err)
+			}
+			got, err := tt.b.ParseQuery(values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Builder.ParseQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1 +1,100 @@
 package goquery
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var plainIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// splitTableName split a possibly schema-qualified table name like
+// `analytics.events` into its parts
+func splitTableName(name string) ([]string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("invalid table name %q", name)
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("invalid table name %q", name)
+	}
+	for _, part := range parts {
+		if part == "" || strings.ContainsRune(part, 0) {
+			return nil, fmt.Errorf("invalid table name %q", name)
+		}
+	}
+	return parts, nil
+}
+
+// tableAlias alias a table is referred to by without an explicit alias, the
+// table name without its schema
+func tableAlias(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// quoteTable quote each part of a possibly schema-qualified table name.
+// Without quoting, only plain identifiers are accepted
+func (b *builderContext) quoteTable(name string) (string, error) {
+	parts, err := splitTableName(name)
+	if err != nil {
+		return "", err
+	}
+	unquoted := b.builder.dialect().QuoteOpen == ""
+	quoted := []string{}
+	for _, part := range parts {
+		if unquoted && !plainIdent.MatchString(part) {
+			return "", fmt.Errorf("invalid table name %q", name)
+		}
+		quoted = append(quoted, b.quote(part))
+	}
+	return strings.Join(quoted, "."), nil
+}
+
+// checkIdent check an identifier that is quoted with quote. Without
+// quoting, only plain identifiers are accepted
+func (b *builderContext) checkIdent(kind string, name string) error {
+	if b.builder.dialect().QuoteOpen == "" && !plainIdent.MatchString(name) {
+		return fmt.Errorf("invalid %s %q", kind, name)
+	}
+	return nil
+}
+
+// checkInclude check the keys, alias and attributes of an include
+func (b *builderContext) checkInclude(include Include) error {
+	idents := [][2]string{
+		{"source key", include.SourceKey},
+		{"foreign key", include.ForeignKey},
+		{"alias", include.As},
+	}
+	if th := include.Through; th != nil {
+		idents = append(idents, [2]string{"source key", th.SourceKey}, [2]string{"foreign key", th.ForeignKey})
+	}
+	for _, v := range include.Attributes {
+		if attr, ok := v.(string); ok {
+			idents = append(idents, [2]string{"attribute", attr})
+		}
+	}
+	for _, ident := range idents {
+		// missing keys are reported when the include is joined
+		if ident[1] == "" {
+			continue
+		}
+		if err := b.checkIdent(ident[0], ident[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFields check the columns compared in a condition tree of an include,
+// they are written into its join condition
+func (b *builderContext) checkFields(node Node) error {
+	var err error
+	Inspect(node, func(n Node) bool {
+		if c, ok := n.(Compare); ok && err == nil {
+			err = b.checkIdent("column", c.Field)
+		}
+		return err == nil
+	})
+	return err
+}
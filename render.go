@@ -0,0 +1,75 @@
+package goquery
+
+import (
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Render render a parsed condition to squirrel
+func (b *Builder) Render(node Node) (sq.Sqlizer, error) {
+	ctx := builderContext{
+		builder: b,
+		rel:     OpAnd,
+	}
+	return ctx.render(node)
+}
+
+func (b *builderContext) render(node Node) (sq.Sqlizer, error) {
+	switch n := node.(type) {
+	case And:
+		{
+			conds, err := b.renderAll(n)
+			if err != nil {
+				return nil, err
+			}
+			return sq.And(conds), nil
+		}
+	case Or:
+		{
+			conds, err := b.renderAll(n)
+			if err != nil {
+				return nil, err
+			}
+			return sq.Or(conds), nil
+		}
+	case Not:
+		{
+			cond, err := b.render(n.Node)
+			if err != nil {
+				return nil, err
+			}
+			return notExpr{cond}, nil
+		}
+	case Compare:
+		{
+			column := b.toFullName(n.Table, n.Field)
+			conds, err := b.wrapOp(n.Op, []entry{{column, n.Value}})
+			if err != nil {
+				return nil, err
+			}
+			return conds[0], nil
+		}
+	case nil:
+		{
+			return nil, errors.New("invalid syntax, empty condition")
+		}
+	default:
+		{
+			return nil, fmt.Errorf("unsupported node %T", node)
+		}
+	}
+}
+
+func (b *builderContext) renderAll(nodes []Node) ([]sq.Sqlizer, error) {
+	conds := []sq.Sqlizer{}
+	for _, n := range nodes {
+		cond, err := b.render(n)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
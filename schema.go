@@ -0,0 +1,297 @@
+package goquery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ColumnType value type of a column, used to check condition values
+type ColumnType int
+
+const (
+	// TypeAny accept any value
+	TypeAny ColumnType = iota
+	// TypeString strings
+	TypeString
+	// TypeNumber integers, floats and numeric strings
+	TypeNumber
+	// TypeBool bools and the strings "true" and "false"
+	TypeBool
+	// TypeTime time.Time and strings
+	TypeTime
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case TypeString:
+		{
+			return "string"
+		}
+	case TypeNumber:
+		{
+			return "number"
+		}
+	case TypeBool:
+		{
+			return "bool"
+		}
+	case TypeTime:
+		{
+			return "time"
+		}
+	default:
+		{
+			return "any"
+		}
+	}
+}
+
+// Access what a column may be used for
+type Access uint8
+
+const (
+	// AccessFilter column may be used in where conditions
+	AccessFilter Access = 1 << iota
+	// AccessSort column may be used in order terms
+	AccessSort
+	// AccessSelect column may be used in attributes
+	AccessSelect
+	// AccessJoin column may be used as a key of an include
+	AccessJoin
+	// AccessAll column may be used anywhere
+	AccessAll = AccessFilter | AccessSort | AccessSelect | AccessJoin
+)
+
+// Column schema of a column, an empty Access means AccessAll
+type Column struct {
+	Type   ColumnType
+	Access Access
+}
+
+// Table columns of a table by name, columns that are not listed cannot be
+// used at all
+type Table map[string]Column
+
+// Schema tables by name
+type Schema map[string]Table
+
+var (
+	// ErrUnknownTable table is not in the schema
+	ErrUnknownTable = errors.New("unknown table")
+	// ErrUnknownColumn column is not in the schema of its table
+	ErrUnknownColumn = errors.New("unknown column")
+	// ErrForbiddenColumn column may not be used this way
+	ErrForbiddenColumn = errors.New("forbidden column")
+	// ErrColumnType value does not match the column type
+	ErrColumnType = errors.New("invalid value for column type")
+)
+
+// SchemaError filter refers to a table or column that the schema does not
+// allow. Err is one of ErrUnknownTable, ErrUnknownColumn,
+// ErrForbiddenColumn or ErrColumnType
+type SchemaError struct {
+	Table  string
+	Column string
+	// Usage "from", "include", "where", "order" or "attributes"
+	Usage string
+	Err   error
+}
+
+func (e *SchemaError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("%s %s: %v", e.Usage, e.Table, e.Err)
+	}
+	return fmt.Sprintf("%s %s.%s: %v", e.Usage, e.Table, e.Column, e.Err)
+}
+
+// Unwrap return Err
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+func (c Column) allows(access Access) bool {
+	if c.Access == 0 {
+		return true
+	}
+	return c.Access&access != 0
+}
+
+// columns selectable columns of a table in name order
+func (t Table) columns() []string {
+	names := []string{}
+	for name, column := range t {
+		if column.allows(AccessSelect) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// column look up a column for a usage
+func (s Schema) column(table string, column string, access Access, usage string) (Column, error) {
+	t, ok := s[table]
+	if !ok {
+		return Column{}, &SchemaError{Table: table, Usage: usage, Err: ErrUnknownTable}
+	}
+	c, ok := t[column]
+	if !ok {
+		return Column{}, &SchemaError{Table: table, Column: column, Usage: usage, Err: ErrUnknownColumn}
+	}
+	if !c.allows(access) {
+		return Column{}, &SchemaError{Table: table, Column: column, Usage: usage, Err: ErrForbiddenColumn}
+	}
+	return c, nil
+}
+
+// validate check a query against BuilderConfig.Schema
+func (b *Builder) validate(query *Query) error {
+	schema := b.config.Schema
+	if schema == nil {
+		return nil
+	}
+	filter := query.Filter
+	// table of each alias
+	tables := map[string]string{query.Table: filter.From}
+	if _, ok := schema[filter.From]; !ok {
+		return &SchemaError{Table: filter.From, Usage: "from", Err: ErrUnknownTable}
+	}
+	for _, iq := range query.includes() {
+		include := iq.Include
+		parent := filter.From
+		if iq.Parent != "" {
+			parent = tables[iq.Parent]
+		}
+		if _, err := schema.column(include.Table, include.ForeignKey, AccessJoin, "include"); err != nil {
+			return err
+		}
+		if _, err := schema.column(parent, include.SourceKey, AccessJoin, "include"); err != nil {
+			return err
+		}
+		if th := include.Through; th != nil {
+			if _, err := schema.column(th.TableName, th.SourceKey, AccessJoin, "include"); err != nil {
+				return err
+			}
+			if _, err := schema.column(th.TableName, th.ForeignKey, AccessJoin, "include"); err != nil {
+				return err
+			}
+		}
+		for _, v := range include.Attributes {
+			attr, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if _, err := schema.column(include.Table, attr, AccessSelect, "attributes"); err != nil {
+				return err
+			}
+		}
+		tables[iq.Alias] = include.Table
+		if th := include.Through; th != nil {
+			tables[iq.Through] = th.TableName
+		}
+	}
+
+	var err error
+	query.Inspect(func(n Node) bool {
+		c, ok := n.(Compare)
+		if !ok || err != nil {
+			return err == nil
+		}
+		table, ok := tables[c.Table]
+		if !ok {
+			err = &SchemaError{Table: c.Table, Column: c.Field, Usage: "where", Err: ErrUnknownTable}
+			return false
+		}
+		var column Column
+		if column, err = schema.column(table, c.Field, AccessFilter, "where"); err != nil {
+			return false
+		}
+		if c.Op != OpIsNull && c.Op != OpNotNull && !column.accepts(c.Value) {
+			err = &SchemaError{Table: table, Column: c.Field, Usage: "where", Err: ErrColumnType}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range filter.Order {
+		term, err := ParseOrder(v)
+		if err != nil {
+			return err
+		}
+		alias, field := splitOrderField(term.Field)
+		if alias == "" {
+			alias = query.Table
+		}
+		table, ok := tables[alias]
+		if !ok {
+			return &SchemaError{Table: alias, Column: field, Usage: "order", Err: ErrUnknownTable}
+		}
+		if _, err := schema.column(table, field, AccessSort, "order"); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range filter.Attributes {
+		attr, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if _, err := schema.column(filter.From, attr, AccessSelect, "attributes"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accepts whether v, or every element of a list v, matches the column type.
+// nil always matches
+func (c Column) accepts(v interface{}) bool {
+	if c.Type == TypeAny || isNull(v) {
+		return true
+	}
+	if isList(v) {
+		rv := reflect.ValueOf(v)
+		for i := 0; i < rv.Len(); i++ {
+			if !c.accepts(rv.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+	if c.Type == TypeBool {
+		if str, ok := v.(string); ok {
+			_, err := strconv.ParseBool(str)
+			return err == nil
+		}
+		_, ok := v.(bool)
+		return ok
+	}
+	class, err := boundClass(v)
+	if err != nil {
+		return false
+	}
+	switch c.Type {
+	case TypeString:
+		{
+			return class == "string"
+		}
+	case TypeNumber:
+		{
+			if class == "string" {
+				_, err := strconv.ParseFloat(reflect.Indirect(reflect.ValueOf(v)).String(), 64)
+				return err == nil
+			}
+			return class == "number"
+		}
+	case TypeTime:
+		{
+			return class == "time" || class == "string"
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestBuilder_Build_schema(t *testing.T) {
+	builder, _ := New(BuilderConfig{
+		Schema: Schema{
+			"users": Table{
+				"id":     {Type: TypeNumber},
+				"name":   {Type: TypeString},
+				"age":    {Type: TypeNumber, Access: AccessFilter | AccessSelect},
+				"org_id": {Type: TypeNumber, Access: AccessJoin},
+			},
+			"posts": Table{
+				"user_id": {Type: TypeNumber},
+				"draft":   {Type: TypeBool},
+			},
+		},
+	})
+	tests := []struct {
+		name    string
+		filter  Filter
+		want    string
+		wantErr error
+	}{
+		{
+			name: "valid",
+			filter: Filter{
+				From:    "users",
+				Where:   map[string]interface{}{"age": map[string]interface{}{"$gte": "18"}, "name": "x"},
+				Include: []Include{{Table: "posts", SourceKey: "id", ForeignKey: "user_id", Where: map[string]interface{}{"draft": false}}},
+				Order:   []interface{}{"-name"},
+			},
+			want: "SELECT users.age AS age, users.id AS id, users.name AS name, posts.draft AS \"posts.draft\", posts.user_id AS \"posts.user_id\" FROM users LEFT JOIN posts ON users.id = posts.user_id AND ((posts.draft = ?)) WHERE ((users.age >= ?) AND (users.name = ?)) ORDER BY users.name DESC",
+		},
+		{
+			name:    "unknown table",
+			filter:  Filter{From: "secrets"},
+			wantErr: ErrUnknownTable,
+		},
+		{
+			name:    "unknown column",
+			filter:  Filter{From: "users", Where: map[string]interface{}{"email": "x"}},
+			wantErr: ErrUnknownColumn,
+		},
+		{
+			name:    "hidden column",
+			filter:  Filter{From: "users", Attributes: []interface{}{"org_id"}},
+			wantErr: ErrForbiddenColumn,
+		},
+		{
+			name:    "not sortable",
+			filter:  Filter{From: "users", Order: []interface{}{"age"}},
+			wantErr: ErrForbiddenColumn,
+		},
+		{
+			name:    "include column",
+			filter:  Filter{From: "users", Include: []Include{{Table: "posts", SourceKey: "id", ForeignKey: "author_id"}}},
+			wantErr: ErrUnknownColumn,
+		},
+		{
+			name:    "column type",
+			filter:  Filter{From: "users", Where: map[string]interface{}{"age": map[string]interface{}{"$in": []interface{}{1, "x"}}}},
+			wantErr: ErrColumnType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := builder.Build(tt.filter)
+			if tt.wantErr != nil {
+				schemaErr, ok := err.(*SchemaError)
+				if !ok || schemaErr.Err != tt.wantErr {
+					t.Errorf("Builder.Build() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Builder.Build() error = %v", err)
+			}
+			got, _, _ := im.ToSql()
+			if got != tt.want {
+				t.Errorf("Builder.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package goquery
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// parentKeyColumn result column of follow-up rows holding the key of their
+// parent row
+const parentKeyColumn = "__parent"
+
+// Querier run a query and return its rows keyed by result column name
+type Querier interface {
+	QueryRows(query sq.Sqlizer) ([]map[string]interface{}, error)
+}
+
+// Plan main query of a filter and the follow-up queries of its separate
+// includes
+type Plan struct {
+	Main     sq.Sqlizer
+	Separate []*SeparateQuery
+
+	builder  *Builder
+	includes []*IncludeQuery
+}
+
+// SeparateQuery follow-up query of a separate include, selecting the
+// included rows of a set of parent rows
+type SeparateQuery struct {
+	Include *IncludeQuery
+	// Name key of the included rows in their parent rows
+	Name string
+	// ParentKey result column of the parent rows the included rows are
+	// looked up by
+	ParentKey string
+	// Separate follow-up queries of nested separate includes
+	Separate []*SeparateQuery
+
+	builder *Builder
+}
+
+// BuildPlan build the main query of filter, which joins all includes except
+// the separate ones, and a follow-up query for each separate include. Limit
+// and offset only apply to the main query, so parents are paginated
+// correctly however many rows are included
+func (b *Builder) BuildPlan(filter Filter) (*Plan, error) {
+	query, err := b.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+	main, err := b.BuildQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{
+		Main:     main,
+		Separate: b.separateQueries(query.Include),
+		builder:  b,
+		includes: query.Include,
+	}, nil
+}
+
+func (b *Builder) separateQueries(includes []*IncludeQuery) []*SeparateQuery {
+	list := []*SeparateQuery{}
+	for _, iq := range includes {
+		if iq.Include.Separate {
+			list = append(list, &SeparateQuery{
+				Include:   iq,
+				Name:      iq.Include.alias(),
+				ParentKey: iq.Include.SourceKey,
+				Separate:  b.separateQueries(iq.Children),
+				builder:   b,
+			})
+		}
+	}
+	return list
+}
+
+// Build build the follow-up query for the given parent keys
+func (s *SeparateQuery) Build(keys []interface{}) (sq.Sqlizer, error) {
+	b := s.builder
+	iq := s.Include
+	include := iq.Include
+	ctx := builderContext{
+		builder:   b,
+		rel:       OpAnd,
+		tableName: iq.Alias,
+	}
+	table, err := ctx.joinTable(include.Table, iq.Alias)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := include.Attributes
+	if len(attrs) == 0 && b.config.Schema != nil {
+		for _, column := range b.config.Schema[include.Table].columns() {
+			attrs = append(attrs, column)
+		}
+	}
+	if len(attrs) > 0 {
+		for _, child := range iq.Children {
+			if child.Include.Separate && !containsAttr(attrs, child.Include.SourceKey) {
+				attrs = append(attrs, child.Include.SourceKey)
+			}
+		}
+	}
+	attributes, err := ctx.attrList(attrs, iq.Alias, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(attributes) == 0 {
+		attributes = []string{ctx.quote(iq.Alias) + ".*"}
+	}
+	if len(iq.Children) > 0 {
+		childAttrs, err := ctx.includeAttrs(iq.Children, iq.Path+".")
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, childAttrs...)
+	}
+
+	keyColumn := ctx.toFullName(iq.Alias, include.ForeignKey)
+	bs := sq.Select().From(table)
+	if th := include.Through; th != nil {
+		throughTable, err := ctx.joinTable(th.TableName, iq.Through)
+		if err != nil {
+			return nil, err
+		}
+		keyColumn = ctx.toFullName(iq.Through, th.SourceKey)
+		src := ctx.toFullName(iq.Through, th.ForeignKey)
+		dst := ctx.toFullName(iq.Alias, include.ForeignKey)
+		bs = bs.Join(fmt.Sprintf("%s ON %s = %s", throughTable, src, dst))
+		if iq.ThroughWhere != nil {
+			where, err := ctx.render(iq.ThroughWhere)
+			if err != nil {
+				return nil, err
+			}
+			bs = bs.Where(where)
+		}
+	}
+	attributes = append(attributes, fmt.Sprintf("%s AS %s", keyColumn, ctx.quoteAlias(parentKeyColumn)))
+	bs = bs.Columns(attributes...)
+
+	if len(iq.Children) > 0 {
+		if bs, err = ctx.addJoins(bs, iq.Alias, iq.Children); err != nil {
+			return nil, err
+		}
+	}
+	bs = bs.Where(sq.Eq{keyColumn: keys})
+	if iq.Where != nil {
+		where, err := ctx.render(iq.Where)
+		if err != nil {
+			return nil, err
+		}
+		bs = bs.Where(where)
+	}
+	return bs.PlaceholderFormat(b.dialect().Placeholder), nil
+}
+
+// Run run the main query and the follow-up queries, nest the joined
+// includes like Builder.Hydrate and add the included rows to their parent
+// rows as a list under the name of the include
+func (p *Plan) Run(q Querier) ([]map[string]interface{}, error) {
+	rows, err := q.QueryRows(p.Main)
+	if err != nil {
+		return nil, err
+	}
+	if p.builder != nil {
+		rows = p.builder.nest(p.includes, rows)
+	}
+	if err := runSeparate(q, p.Separate, rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func runSeparate(q Querier, queries []*SeparateQuery, rows []map[string]interface{}) error {
+	for _, s := range queries {
+		keys := []interface{}{}
+		seen := map[string]bool{}
+		for _, row := range rows {
+			row[s.Name] = []map[string]interface{}{}
+			key, ok := row[s.ParentKey]
+			if !ok {
+				return fmt.Errorf("include %s: parent rows have no column %s", s.Include.Path, s.ParentKey)
+			}
+			if key == nil || seen[keyString(key)] {
+				continue
+			}
+			seen[keyString(key)] = true
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		query, err := s.Build(keys)
+		if err != nil {
+			return err
+		}
+		children, err := q.QueryRows(query)
+		if err != nil {
+			return err
+		}
+		children = s.builder.nest(s.Include.Children, children)
+		if err := runSeparate(q, s.Separate, children); err != nil {
+			return err
+		}
+		byKey := map[string][]map[string]interface{}{}
+		for _, child := range children {
+			key := keyString(child[parentKeyColumn])
+			delete(child, parentKeyColumn)
+			byKey[key] = append(byKey[key], child)
+		}
+		for _, row := range rows {
+			if key := row[s.ParentKey]; key != nil {
+				if list, ok := byKey[keyString(key)]; ok {
+					row[s.Name] = list
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// keyString comparable form of a key, drivers may return the same key as
+// different types
+func keyString(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,125 @@
+package goquery
+
+import (
+	"reflect"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type stubQuerier struct {
+	queries []string
+	args    [][]interface{}
+	results [][]map[string]interface{}
+}
+
+func (q *stubQuerier) QueryRows(query sq.Sqlizer) ([]map[string]interface{}, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	q.queries = append(q.queries, sql)
+	q.args = append(q.args, args)
+	rows := q.results[0]
+	q.results = q.results[1:]
+	return rows, nil
+}
+
+func TestPlan_Run(t *testing.T) {
+	models := Models{
+		"posts": Model{
+			Associations: map[string]Association{
+				"comments": {Kind: HasMany, Table: "comments", ForeignKey: "post_id"},
+				"tags": {
+					Kind:    BelongsToMany,
+					Table:   "tags",
+					Through: &IncludeThrough{TableName: "post_tags", SourceKey: "post_id", ForeignKey: "tag_id"},
+				},
+			},
+		},
+	}
+	builder, _ := New(BuilderConfig{Models: models})
+	limit := uint64(2)
+	plan, err := builder.BuildPlan(Filter{
+		From:       "posts",
+		Attributes: []interface{}{"title"},
+		Include: []Include{
+			{Association: "comments", Separate: true, Where: map[string]interface{}{"approved": true}},
+			{Association: "tags", Separate: true, Attributes: []interface{}{"label"}},
+		},
+		Limit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("Builder.BuildPlan() error = %v", err)
+	}
+	q := &stubQuerier{
+		results: [][]map[string]interface{}{
+			{{"id": int64(1), "title": "a"}, {"id": int64(2), "title": "b"}},
+			{{"id": int64(10), "body": "x", "__parent": int64(1)}, {"id": int64(11), "body": "y", "__parent": int64(1)}},
+			{{"label": "go", "__parent": []byte("2")}},
+		},
+	}
+	rows, err := plan.Run(q)
+	if err != nil {
+		t.Fatalf("Plan.Run() error = %v", err)
+	}
+	wantQueries := []string{
+		"SELECT posts.title AS title, posts.id AS id FROM posts WHERE (1=1) LIMIT 2",
+		`SELECT comments.*, comments.post_id AS "__parent" FROM comments WHERE comments.post_id IN (?,?) AND ((comments.approved = ?))`,
+		`SELECT tags.label AS label, tags__post_tags.post_id AS "__parent" FROM tags JOIN post_tags AS tags__post_tags ON tags__post_tags.tag_id = tags.id WHERE tags__post_tags.post_id IN (?,?)`,
+	}
+	if !reflect.DeepEqual(q.queries, wantQueries) {
+		t.Errorf("Plan.Run() queries = %v, want %v", q.queries, wantQueries)
+	}
+	wantArgs := []interface{}{int64(1), int64(2), true}
+	if !reflect.DeepEqual(q.args[1], wantArgs) {
+		t.Errorf("Plan.Run() args = %v, want %v", q.args[1], wantArgs)
+	}
+	want := []map[string]interface{}{
+		{
+			"id":    int64(1),
+			"title": "a",
+			"comments": []map[string]interface{}{
+				{"id": int64(10), "body": "x"},
+				{"id": int64(11), "body": "y"},
+			},
+			"tags": []map[string]interface{}{},
+		},
+		{
+			"id":       int64(2),
+			"title":    "b",
+			"comments": []map[string]interface{}{},
+			"tags":     []map[string]interface{}{{"label": "go"}},
+		},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Plan.Run() = %v, want %v", rows, want)
+	}
+}
+
+func TestBuilder_BuildPlan_invalid(t *testing.T) {
+	builder, _ := New(BuilderConfig{})
+	tests := []struct {
+		name    string
+		include Include
+	}{
+		{
+			name:    "required",
+			include: Include{Table: "comments", SourceKey: "id", ForeignKey: "post_id", Separate: true, Required: true},
+		},
+		{
+			name: "below joined include",
+			include: Include{
+				Table: "comments", SourceKey: "id", ForeignKey: "post_id",
+				Include: []Include{{Table: "likes", SourceKey: "id", ForeignKey: "comment_id", Separate: true}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := builder.BuildPlan(Filter{From: "posts", Include: []Include{tt.include}}); err == nil {
+				t.Errorf("Builder.BuildPlan() error = nil, want error")
+			}
+		})
+	}
+}